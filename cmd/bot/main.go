@@ -76,11 +76,12 @@ func main() {
 	// Initialize webhook server (if enabled)
 	var webhookServer *webhook.Server
 	if cfg.Webhooks.Enabled {
-		webhookServer = webhook.NewServer(
-			cfg.Webhooks.Listen,
-			cfg.Webhooks.Secret,
-			bot,
-		)
+		webhookServer, err = webhook.NewServer(cfg, bot)
+		if err != nil {
+			log.Fatalf("Failed to create webhook server: %v", err)
+		}
+		webhookServer.SetEventRecorder(bot)
+		webhookServer.SetSilencer(bot)
 		log.Printf("Webhook receiver enabled on %s", cfg.Webhooks.Listen)
 	}
 
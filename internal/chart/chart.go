@@ -0,0 +1,440 @@
+// Package chart renders time series as PNG images without external dependencies
+package chart
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+	"time"
+)
+
+// Kind is the chart style
+type Kind string
+
+const (
+	KindLine Kind = "line" // lines only
+	KindArea Kind = "area" // lines with translucent fill down to the baseline
+)
+
+// Default image size in pixels
+const (
+	DefaultWidth  = 1000
+	DefaultHeight = 500
+)
+
+// ErrNoData is returned by Render if no series has points
+var ErrNoData = errors.New("no data points")
+
+// Point is one sample of a series
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series is a named line of the chart
+type Series struct {
+	Name   string
+	Points []Point // sorted by time
+}
+
+// Chart describes a time series chart
+type Chart struct {
+	Title  string
+	Kind   Kind
+	Series []Series
+
+	Start, End time.Time     // time axis range (zero: from the data)
+	Step       time.Duration // sample interval; gaps longer than 2 steps break lines (0: never)
+	YMax       float64       // fixed upper bound of the value axis (0: from the data)
+
+	// FormatValue formats value axis labels (nil: %g)
+	FormatValue func(float64) string
+
+	Width, Height int // image size (0: DefaultWidth x DefaultHeight)
+}
+
+// palette are the series colors, used in order
+var palette = []color.RGBA{
+	{0x1f, 0x77, 0xb4, 0xff}, // blue
+	{0xff, 0x7f, 0x0e, 0xff}, // orange
+	{0x2c, 0xa0, 0x2c, 0xff}, // green
+	{0xd6, 0x27, 0x28, 0xff}, // red
+	{0x94, 0x67, 0xbd, 0xff}, // purple
+	{0x8c, 0x56, 0x4b, 0xff}, // brown
+	{0xe3, 0x77, 0xc2, 0xff}, // pink
+	{0x7f, 0x7f, 0x7f, 0xff}, // gray
+	{0xbc, 0xbd, 0x22, 0xff}, // olive
+	{0x17, 0xbe, 0xcf, 0xff}, // cyan
+}
+
+var (
+	colorBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	colorText       = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	colorAxis       = color.RGBA{0x99, 0x99, 0x99, 0xff}
+	colorGrid       = color.RGBA{0xe5, 0xe5, 0xe5, 0xff}
+)
+
+// Layout constants in pixels
+const (
+	textScale  = 2                            // font scale factor
+	charWidth  = (glyphWidth + 1) * textScale // advance per character
+	textHeight = glyphHeight * textScale      // height of a text line
+	margin     = 20                           // outer margin
+	areaAlpha  = 0.18                         // opacity of area fills
+	yTickCount = 5                            // approximate number of value ticks
+	xTickCount = 8                            // maximum number of time ticks
+	lineWidth  = 2                            // series line thickness
+	legendBox  = textHeight                   // legend color square size
+	legendGap  = 3 * charWidth                // space between legend entries
+	titleSpace = textHeight + margin          // space above the plot
+	xAxisSpace = textHeight + margin/2        // space below the plot for time labels
+	legendLine = textHeight + margin/2        // height of one legend line
+)
+
+// timeSteps are the candidate distances between time axis ticks
+var timeSteps = []time.Duration{
+	time.Minute, 2 * time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 2 * time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour,
+	24 * time.Hour, 2 * 24 * time.Hour, 7 * 24 * time.Hour, 14 * 24 * time.Hour, 30 * 24 * time.Hour,
+}
+
+// Render draws the chart and encodes it as PNG
+func (c *Chart) Render() ([]byte, error) {
+	width, height := c.Width, c.Height
+	if width <= 0 {
+		width = DefaultWidth
+	}
+	if height <= 0 {
+		height = DefaultHeight
+	}
+
+	start, end, minValue, maxValue, ok := c.bounds()
+	if !ok {
+		return nil, ErrNoData
+	}
+	ticks, minValue, maxValue := valueTicks(minValue, maxValue, c.YMax)
+
+	formatValue := c.FormatValue
+	if formatValue == nil {
+		formatValue = func(v float64) string { return fmt.Sprintf("%g", v) }
+	}
+	labelWidth := 0
+	for _, tick := range ticks {
+		labelWidth = max(labelWidth, textWidth(formatValue(tick)))
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fillRect(img, img.Bounds(), colorBackground)
+
+	legendLines := c.legendLayout(width - 2*margin)
+	plot := image.Rect(
+		margin+labelWidth+margin/2,
+		titleSpace+margin/2,
+		width-margin,
+		height-margin-xAxisSpace-len(legendLines)*legendLine,
+	)
+	if plot.Dx() < 10 || plot.Dy() < 10 {
+		return nil, fmt.Errorf("chart too small: %dx%d", width, height)
+	}
+
+	xOf := func(t time.Time) float64 {
+		return float64(plot.Min.X) + float64(t.Sub(start))/float64(end.Sub(start))*float64(plot.Dx()-1)
+	}
+	yOf := func(v float64) float64 {
+		return float64(plot.Max.Y-1) - (v-minValue)/(maxValue-minValue)*float64(plot.Dy()-1)
+	}
+
+	// Title
+	drawText(img, margin, margin, c.Title, colorText)
+
+	// Value grid and labels
+	for _, tick := range ticks {
+		y := int(math.Round(yOf(tick)))
+		hline(img, plot.Min.X, plot.Max.X, y, colorGrid)
+		label := formatValue(tick)
+		drawText(img, plot.Min.X-margin/2-textWidth(label), y-textHeight/2, label, colorText)
+	}
+
+	// Time grid and labels
+	step := timeStep(end.Sub(start))
+	layout := "15:04"
+	if end.Sub(start) > 36*time.Hour {
+		layout = "Jan 2"
+	}
+	for t := firstTick(start, step); !t.After(end); t = t.Add(step) {
+		x := int(math.Round(xOf(t)))
+		vline(img, x, plot.Min.Y, plot.Max.Y, colorGrid)
+		label := t.Format(layout)
+		lx := min(max(x-textWidth(label)/2, plot.Min.X), plot.Max.X-textWidth(label))
+		drawText(img, lx, plot.Max.Y+margin/2, label, colorText)
+	}
+
+	// Axes
+	hline(img, plot.Min.X, plot.Max.X, plot.Max.Y-1, colorAxis)
+	vline(img, plot.Min.X, plot.Min.Y, plot.Max.Y, colorAxis)
+
+	// Series
+	baseline := yOf(math.Max(minValue, math.Min(0, maxValue)))
+	for i, s := range c.Series {
+		col := palette[i%len(palette)]
+		for _, segment := range c.segments(s.Points) {
+			for j := 1; j < len(segment); j++ {
+				x0, y0 := xOf(segment[j-1].Time), yOf(segment[j-1].Value)
+				x1, y1 := xOf(segment[j].Time), yOf(segment[j].Value)
+				if c.Kind == KindArea {
+					fillArea(img, plot, x0, y0, x1, y1, baseline, col)
+				}
+				drawLine(img, plot, x0, y0, x1, y1, col)
+			}
+			if len(segment) == 1 {
+				x, y := int(math.Round(xOf(segment[0].Time))), int(math.Round(yOf(segment[0].Value)))
+				fillRect(img, image.Rect(x-lineWidth, y-lineWidth, x+lineWidth, y+lineWidth).Intersect(plot), col)
+			}
+		}
+	}
+
+	// Legend
+	y := plot.Max.Y + xAxisSpace + margin/2
+	for _, line := range legendLines {
+		x := margin
+		for _, i := range line {
+			fillRect(img, image.Rect(x, y, x+legendBox, y+legendBox), palette[i%len(palette)])
+			x += legendBox + charWidth
+			drawText(img, x, y, c.Series[i].Name, colorText)
+			x += textWidth(c.Series[i].Name) + legendGap
+		}
+		y += legendLine
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode chart: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// bounds returns the time and value ranges of the chart
+func (c *Chart) bounds() (start, end time.Time, minValue, maxValue float64, ok bool) {
+	minValue, maxValue = math.Inf(1), math.Inf(-1)
+	for _, s := range c.Series {
+		for _, p := range s.Points {
+			if !ok || p.Time.Before(start) {
+				start = p.Time
+			}
+			if !ok || p.Time.After(end) {
+				end = p.Time
+			}
+			minValue = math.Min(minValue, p.Value)
+			maxValue = math.Max(maxValue, p.Value)
+			ok = true
+		}
+	}
+	if !ok {
+		return
+	}
+	if !c.Start.IsZero() {
+		start = c.Start
+	}
+	if !c.End.IsZero() {
+		end = c.End
+	}
+	if !end.After(start) {
+		start, end = start.Add(-time.Minute), start.Add(time.Minute)
+	}
+	return
+}
+
+// segments splits points at gaps longer than two steps
+func (c *Chart) segments(points []Point) [][]Point {
+	var segments [][]Point
+	begin := 0
+	for i := 1; i <= len(points); i++ {
+		if i == len(points) || (c.Step > 0 && points[i].Time.Sub(points[i-1].Time) > 2*c.Step) {
+			if i > begin {
+				segments = append(segments, points[begin:i])
+			}
+			begin = i
+		}
+	}
+	return segments
+}
+
+// legendLayout wraps legend entries into lines (series indexes per line)
+// Charts with a single unnamed series have no legend
+func (c *Chart) legendLayout(width int) [][]int {
+	if len(c.Series) == 1 && c.Series[0].Name == "" {
+		return nil
+	}
+	var lines [][]int
+	var line []int
+	x := 0
+	for i, s := range c.Series {
+		w := legendBox + charWidth + textWidth(s.Name)
+		if len(line) > 0 && x+w > width {
+			lines = append(lines, line)
+			line, x = nil, 0
+		}
+		line = append(line, i)
+		x += w + legendGap
+	}
+	if len(line) > 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// valueTicks returns evenly spaced "nice" ticks covering [minValue, maxValue]
+// and the adjusted axis range; a positive fixedMax is used as the upper bound
+func valueTicks(minValue, maxValue, fixedMax float64) ([]float64, float64, float64) {
+	minValue = math.Min(minValue, 0)
+	if fixedMax > 0 {
+		maxValue = math.Max(maxValue, fixedMax)
+	}
+	if maxValue <= minValue {
+		maxValue = minValue + 1
+	}
+
+	step := niceStep((maxValue - minValue) / yTickCount)
+	low := math.Floor(minValue/step) * step
+	high := math.Ceil(maxValue/step) * step
+	if fixedMax > 0 && maxValue == fixedMax {
+		high = fixedMax
+	}
+
+	var ticks []float64
+	for v := low; v <= high+step/2; v += step {
+		ticks = append(ticks, math.Round(v/step)*step)
+	}
+	return ticks, low, high
+}
+
+// niceStep rounds rough up to 1, 2 or 5 times a power of ten
+func niceStep(rough float64) float64 {
+	magnitude := math.Pow(10, math.Floor(math.Log10(rough)))
+	switch norm := rough / magnitude; {
+	case norm <= 1:
+		return magnitude
+	case norm <= 2:
+		return 2 * magnitude
+	case norm <= 5:
+		return 5 * magnitude
+	default:
+		return 10 * magnitude
+	}
+}
+
+// timeStep returns the smallest tick distance giving at most xTickCount ticks
+func timeStep(span time.Duration) time.Duration {
+	for _, step := range timeSteps {
+		if span/step <= xTickCount {
+			return step
+		}
+	}
+	return timeSteps[len(timeSteps)-1]
+}
+
+// firstTick returns the first multiple of step at or after t (in local time)
+func firstTick(t time.Time, step time.Duration) time.Time {
+	if step >= 24*time.Hour {
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		if day.Before(t) {
+			day = day.AddDate(0, 0, 1)
+		}
+		return day
+	}
+	_, offset := t.Zone()
+	shift := time.Duration(offset) * time.Second
+	tick := t.Add(shift).Truncate(step).Add(-shift)
+	if tick.Before(t) {
+		tick = tick.Add(step)
+	}
+	return tick
+}
+
+// textWidth returns the width of text in pixels
+func textWidth(text string) int {
+	n := len([]rune(text))
+	if n == 0 {
+		return 0
+	}
+	return n*charWidth - textScale
+}
+
+// drawText draws text with its top-left corner at (x, y)
+func drawText(img *image.RGBA, x, y int, text string, col color.RGBA) {
+	for _, r := range strings.ToUpper(text) {
+		glyph, ok := glyphs[r]
+		if !ok {
+			glyph = glyphs['?']
+		}
+		for row, bits := range glyph {
+			for column := 0; column < glyphWidth; column++ {
+				if bits&(1<<(glyphWidth-1-column)) == 0 {
+					continue
+				}
+				px, py := x+column*textScale, y+row*textScale
+				fillRect(img, image.Rect(px, py, px+textScale, py+textScale), col)
+			}
+		}
+		x += charWidth
+	}
+}
+
+// fillRect fills r with col
+func fillRect(img *image.RGBA, r image.Rectangle, col color.RGBA) {
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetRGBA(x, y, col)
+		}
+	}
+}
+
+// hline draws a horizontal line from x0 to x1 (exclusive)
+func hline(img *image.RGBA, x0, x1, y int, col color.RGBA) {
+	fillRect(img, image.Rect(x0, y, x1, y+1), col)
+}
+
+// vline draws a vertical line from y0 to y1 (exclusive)
+func vline(img *image.RGBA, x, y0, y1 int, col color.RGBA) {
+	fillRect(img, image.Rect(x, y0, x+1, y1), col)
+}
+
+// drawLine draws a thick line clipped to clip
+func drawLine(img *image.RGBA, clip image.Rectangle, x0, y0, x1, y1 float64, col color.RGBA) {
+	steps := int(math.Max(math.Abs(x1-x0), math.Abs(y1-y0))) + 1
+	for i := 0; i <= steps; i++ {
+		f := float64(i) / float64(steps)
+		x := int(math.Round(x0 + (x1-x0)*f))
+		y := int(math.Round(y0 + (y1-y0)*f))
+		fillRect(img, image.Rect(x, y, x+lineWidth, y+lineWidth).Intersect(clip), col)
+	}
+}
+
+// fillArea blends col between the segment and the baseline, one column at a time
+func fillArea(img *image.RGBA, clip image.Rectangle, x0, y0, x1, y1, baseline float64, col color.RGBA) {
+	from, to := int(math.Round(x0)), int(math.Round(x1))
+	for x := from; x < to; x++ {
+		if x < clip.Min.X || x >= clip.Max.X {
+			continue
+		}
+		y := y0 + (y1-y0)*(float64(x)-x0)/(x1-x0)
+		top, bottom := int(math.Round(math.Min(y, baseline))), int(math.Round(math.Max(y, baseline)))
+		for py := max(top, clip.Min.Y); py < min(bottom, clip.Max.Y); py++ {
+			img.SetRGBA(x, py, blend(img.RGBAAt(x, py), col, areaAlpha))
+		}
+	}
+}
+
+// blend mixes src over dst with opacity alpha
+func blend(dst, src color.RGBA, alpha float64) color.RGBA {
+	mix := func(d, s uint8) uint8 {
+		return uint8(math.Round(float64(d)*(1-alpha) + float64(s)*alpha))
+	}
+	return color.RGBA{mix(dst.R, src.R), mix(dst.G, src.G), mix(dst.B, src.B), 0xff}
+}
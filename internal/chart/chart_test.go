@@ -0,0 +1,112 @@
+package chart
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNiceStep(t *testing.T) {
+	tests := []struct {
+		rough float64
+		want  float64
+	}{
+		{1, 1},
+		{2, 2},
+		{7, 10},
+		{15, 20},
+		{120, 200},
+		{0.3, 0.5},
+		{0.01, 0.01},
+		{246.8, 500},
+	}
+	for _, tt := range tests {
+		if got := niceStep(tt.rough); math.Abs(got-tt.want) > 1e-9*tt.want {
+			t.Errorf("niceStep(%v) = %v, want %v", tt.rough, got, tt.want)
+		}
+	}
+}
+
+func TestValueTicks(t *testing.T) {
+	tests := []struct {
+		name                      string
+		minValue, maxValue, fixed float64
+		wantTicks                 []float64
+		wantLow, wantHigh         float64
+	}{
+		{
+			name:     "percent below fixed max",
+			minValue: 0, maxValue: 37, fixed: 100,
+			wantTicks: []float64{0, 20, 40, 60, 80, 100},
+			wantLow:   0, wantHigh: 100,
+		},
+		{
+			name:     "percent above fixed max",
+			minValue: 0, maxValue: 150, fixed: 100,
+			wantTicks: []float64{0, 50, 100, 150},
+			wantLow:   0, wantHigh: 150,
+		},
+		{
+			name:     "axis starts at zero",
+			minValue: 5, maxValue: 7,
+			wantTicks: []float64{0, 2, 4, 6, 8},
+			wantLow:   0, wantHigh: 8,
+		},
+		{
+			name:     "negative values",
+			minValue: -3, maxValue: 7,
+			wantTicks: []float64{-4, -2, 0, 2, 4, 6, 8},
+			wantLow:   -4, wantHigh: 8,
+		},
+		{
+			name:     "large values",
+			minValue: 0, maxValue: 1234,
+			wantTicks: []float64{0, 500, 1000, 1500},
+			wantLow:   0, wantHigh: 1500,
+		},
+		{
+			name:     "flat zero series",
+			minValue: 0, maxValue: 0,
+			wantTicks: []float64{0, 0.2, 0.4, 0.6, 0.8, 1},
+			wantLow:   0, wantHigh: 1,
+		},
+	}
+
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticks, low, high := valueTicks(tt.minValue, tt.maxValue, tt.fixed)
+			if !near(low, tt.wantLow) || !near(high, tt.wantHigh) {
+				t.Errorf("valueTicks() range = [%v, %v], want [%v, %v]", low, high, tt.wantLow, tt.wantHigh)
+			}
+			if len(ticks) != len(tt.wantTicks) {
+				t.Fatalf("valueTicks() ticks = %v, want %v", ticks, tt.wantTicks)
+			}
+			for i := range ticks {
+				if !near(ticks[i], tt.wantTicks[i]) {
+					t.Fatalf("valueTicks() ticks = %v, want %v", ticks, tt.wantTicks)
+				}
+			}
+		})
+	}
+}
+
+func TestTimeStep(t *testing.T) {
+	tests := []struct {
+		span time.Duration
+		want time.Duration
+	}{
+		{5 * time.Minute, time.Minute},
+		{time.Hour, 10 * time.Minute},
+		{6 * time.Hour, time.Hour},
+		{24 * time.Hour, 3 * time.Hour},
+		{7 * 24 * time.Hour, 24 * time.Hour},
+		{30 * 24 * time.Hour, 7 * 24 * time.Hour},
+		{365 * 24 * time.Hour, 30 * 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := timeStep(tt.span); got != tt.want {
+			t.Errorf("timeStep(%s) = %s, want %s", tt.span, got, tt.want)
+		}
+	}
+}
@@ -1,8 +1,13 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"log"
+	"net"
 	"os"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -15,45 +20,410 @@ type Config struct {
 	Logging        LoggingConfig        `yaml:"logging"`
 	Infrastructure InfrastructureConfig `yaml:"infrastructure"`
 	S3             S3Config             `yaml:"s3"`
+	Storage        StorageConfig        `yaml:"storage"`
+}
+
+// StorageConfig configures local persistence of bot state
+type StorageConfig struct {
+	DataDir           string        `yaml:"data_dir"`           // Directory for state files (default /var/lib/scinfra-bot)
+	IncidentRetention time.Duration `yaml:"incident_retention"` // How long closed incidents are kept (default 90 days)
 }
 
 // InfrastructureConfig configures infrastructure monitoring
 type InfrastructureConfig struct {
+	Enabled          bool                      `yaml:"enabled"`
+	PrometheusURL    string                    `yaml:"prometheus_url"`
+	Datasources      []DatasourceConfig        `yaml:"datasources"`       // Named Prometheus data sources (clouds select one)
+	Exporters        map[string]ExporterConfig `yaml:"exporters"`         // Query template overrides and custom exporter types
+	ProbeConcurrency int                       `yaml:"probe_concurrency"` // Servers probed in parallel (default 4)
+	ProbeTimeout     time.Duration             `yaml:"probe_timeout"`     // Per-server probe deadline (default 30s)
+	Alerts           AlertsConfig              `yaml:"alerts"`
+	Thresholds       ThresholdsConfig          `yaml:"thresholds"`  // Global thresholds (cloud and server blocks override)
+	Maintenance      []MaintenanceWindow       `yaml:"maintenance"` // Scheduled silences
+	SLAReport        SLAReportConfig           `yaml:"sla_report"`  // Scheduled availability report
+	PromQL           PromQLConfig              `yaml:"promql"`      // Ad-hoc queries (/promql) and saved queries (/q)
+	Discovery        DiscoveryConfig           `yaml:"discovery"`   // Services and servers from Prometheus targets
+	Clouds           []CloudConfig             `yaml:"clouds"`
+}
+
+// DefaultDatasource is the data source of clouds without `datasource`
+// Defined from prometheus_url unless configured explicitly
+const DefaultDatasource = "default"
+
+// DatasourceConfig configures a Prometheus data source
+type DatasourceConfig struct {
+	Name               string            `yaml:"name"`
+	URL                string            `yaml:"url"`
+	BasicAuth          BasicAuthConfig   `yaml:"basic_auth"`
+	BearerToken        string            `yaml:"bearer_token"`
+	CAFile             string            `yaml:"ca_file"`              // PEM bundle to verify the server certificate
+	CertFile           string            `yaml:"cert_file"`            // Client certificate (PEM)
+	KeyFile            string            `yaml:"key_file"`             // Client key (PEM)
+	InsecureSkipVerify bool              `yaml:"insecure_skip_verify"` // Do not verify the server certificate
+	Headers            map[string]string `yaml:"headers"`              // Extra request headers
+	Timeout            time.Duration     `yaml:"timeout"`              // Request timeout (default 10s)
+}
+
+// Built-in exporter types (server `exporter`)
+const (
+	ExporterNode     = "node_exporter"
+	ExporterWindows  = "windows_exporter"
+	ExporterCAdvisor = "cadvisor"
+)
+
+// ExporterConfig overrides the job and query templates of an exporter type,
+// or defines a new one
+type ExporterConfig struct {
+	Job     string            `yaml:"job"`     // Job label of the exporter's scrape target
+	Queries map[string]string `yaml:"queries"` // Metric name -> PromQL template
+}
+
+// PromQLConfig configures ad-hoc PromQL queries and saved queries
+type PromQLConfig struct {
+	MaxRows int                   `yaml:"max_rows"` // Larger results are sent as CSV (default 20)
+	Queries map[string]SavedQuery `yaml:"queries"`  // Saved queries by name (/q <name>)
+}
+
+// SavedQuery is a named PromQL query
+type SavedQuery struct {
+	Query       string `yaml:"query"`
+	Description string `yaml:"description"`
+	Datasource  string `yaml:"datasource"` // Data source name (default: default)
+}
+
+// DiscoveryConfig configures discovery of services and servers from Prometheus scrape targets
+type DiscoveryConfig struct {
+	Enabled       bool     `yaml:"enabled"`
+	CreateServers bool     `yaml:"create_servers"` // Add unmatched exporter instances as servers
+	Cloud         string   `yaml:"cloud"`          // Cloud of created servers (default "Discovered")
+	IgnoreJobs    []string `yaml:"ignore_jobs"`    // Jobs never attached or reported (default: prometheus)
+}
+
+// AlertsConfig configures background health polling and state-transition alerts
+type AlertsConfig struct {
 	Enabled       bool          `yaml:"enabled"`
-	PrometheusURL string        `yaml:"prometheus_url"`
-	Clouds        []CloudConfig `yaml:"clouds"`
+	Interval      time.Duration `yaml:"interval"`       // Poll interval (default 60s)
+	MinDuration   time.Duration `yaml:"min_duration"`   // New state must persist this long before alerting (default 2m)
+	FlapWindow    time.Duration `yaml:"flap_window"`    // Window for flap detection (default 30m)
+	FlapThreshold int           `yaml:"flap_threshold"` // State changes within window to consider flapping (default 4)
+}
+
+// SLA report periods
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// SLAReportConfig configures the scheduled availability report
+type SLAReportConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Schedule string `yaml:"schedule"` // Local time: "daily 09:00", "mon 09:00" or "monthly 09:00" (1st of month)
+	Period   string `yaml:"period"`   // day, week or month (default from schedule)
+	CSV      bool   `yaml:"csv"`      // Attach the report as CSV
+}
+
+// parseSchedule parses a report schedule into its kind ("daily", "weekly" or
+// "monthly"), weekday (weekly only) and minutes since midnight
+func parseSchedule(s string) (kind string, wd time.Weekday, minutes int, err error) {
+	var day string
+	var hour, minute int
+	if _, err := fmt.Sscanf(strings.ToLower(s), "%s %d:%d", &day, &hour, &minute); err != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return "", 0, 0, fmt.Errorf("invalid schedule %q (expected e.g. \"mon 09:00\")", s)
+	}
+	switch day {
+	case "daily", "monthly":
+		return day, 0, hour*60 + minute, nil
+	}
+	wd, minutes, err = parseWeekly(s)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("invalid schedule %q (expected e.g. \"mon 09:00\")", s)
+	}
+	return "weekly", wd, minutes, nil
+}
+
+// Next returns the first scheduled report time after t
+func (r SLAReportConfig) Next(t time.Time) (time.Time, error) {
+	kind, wd, minutes, err := parseSchedule(r.Schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t = t.Local()
+	next := time.Date(t.Year(), t.Month(), t.Day(), minutes/60, minutes%60, 0, 0, t.Location())
+	switch kind {
+	case "daily":
+		if !next.After(t) {
+			next = next.AddDate(0, 0, 1)
+		}
+	case "weekly":
+		next = next.AddDate(0, 0, (int(wd)-int(t.Weekday())+7)%7)
+		if !next.After(t) {
+			next = next.AddDate(0, 0, 7)
+		}
+	case "monthly":
+		next = next.AddDate(0, 0, 1-t.Day())
+		if !next.After(t) {
+			next = next.AddDate(0, 1, 0)
+		}
+	}
+	return next, nil
+}
+
+// MaintenanceWindow is a scheduled silence: one-off (start) or weekly
+type MaintenanceWindow struct {
+	Target   string        `yaml:"target"`   // Server ID, "server/service" or cloud name
+	Reason   string        `yaml:"reason"`   // Shown in /maintenance
+	Start    time.Time     `yaml:"start"`    // One-off window start (RFC 3339)
+	Weekly   string        `yaml:"weekly"`   // Recurring start in local time, e.g. "sun 03:00"
+	Duration time.Duration `yaml:"duration"` // Window length
+}
+
+// weekdays maps short weekday names to time.Weekday
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseWeekly parses "sun 03:00" into weekday and minutes since midnight
+func parseWeekly(s string) (time.Weekday, int, error) {
+	var day string
+	var hour, minute int
+	if _, err := fmt.Sscanf(strings.ToLower(s), "%s %d:%d", &day, &hour, &minute); err != nil {
+		return 0, 0, fmt.Errorf("invalid weekly %q (expected e.g. \"sun 03:00\")", s)
+	}
+	wd, ok := weekdays[day[:min(3, len(day))]]
+	if !ok || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return 0, 0, fmt.Errorf("invalid weekly %q (expected e.g. \"sun 03:00\")", s)
+	}
+	return wd, hour*60 + minute, nil
+}
+
+// ActiveAt returns the window occurrence covering now, if any
+func (w MaintenanceWindow) ActiveAt(now time.Time) (start, end time.Time, ok bool) {
+	if w.Weekly == "" {
+		start = w.Start
+	} else {
+		wd, minutes, err := parseWeekly(w.Weekly)
+		if err != nil {
+			return time.Time{}, time.Time{}, false
+		}
+		// Most recent occurrence at or before now
+		now = now.Local()
+		start = time.Date(now.Year(), now.Month(), now.Day(), minutes/60, minutes%60, 0, 0, now.Location())
+		start = start.AddDate(0, 0, -((int(now.Weekday()) - int(wd) + 7) % 7))
+		if start.After(now) {
+			start = start.AddDate(0, 0, -7)
+		}
+	}
+	end = start.Add(w.Duration)
+	return start, end, !now.Before(start) && now.Before(end)
 }
 
 // CloudConfig represents a cloud provider with servers
 type CloudConfig struct {
-	Name    string         `yaml:"name"` // "Production"
-	Icon    string         `yaml:"icon"` // "☁️"
-	Servers []ServerConfig `yaml:"servers"`
+	Name       string           `yaml:"name"`       // "Production"
+	Icon       string           `yaml:"icon"`       // "☁️"
+	Thresholds ThresholdsConfig `yaml:"thresholds"` // Overrides global thresholds
+	Datasource string           `yaml:"datasource"` // Prometheus data source name (default: "default")
+	Servers    []ServerConfig   `yaml:"servers"`
 }
 
 // ServerConfig represents a server to monitor
 type ServerConfig struct {
-	ID                 string          `yaml:"id"`                  // Unique identifier
-	Name               string          `yaml:"name"`                // Display name
-	Icon               string          `yaml:"icon"`                // "🖥️"
-	IP                 string          `yaml:"ip"`                  // "10.0.1.11"
-	ExternalCheck      string          `yaml:"external_check"`      // "https://51.250.11.142" or "tcp://..."
-	PrometheusInstance string          `yaml:"prometheus_instance"` // Instance label for Prometheus queries
-	Services           []ServiceConfig `yaml:"services"`
+	ID                 string           `yaml:"id"`                  // Unique identifier
+	Name               string           `yaml:"name"`                // Display name
+	Icon               string           `yaml:"icon"`                // "🖥️"
+	IP                 string           `yaml:"ip"`                  // "10.0.1.11"
+	ExternalCheck      string           `yaml:"external_check"`      // "https://51.250.11.142" or "tcp://..."
+	ExternalChecks     []string         `yaml:"external_checks"`     // Additional checks (dns://, udp://, assertions)
+	PrometheusInstance string           `yaml:"prometheus_instance"` // Instance label for Prometheus queries
+	Exporter           string           `yaml:"exporter"`            // Exporter type for Prometheus queries (default node_exporter)
+	Thresholds         ThresholdsConfig `yaml:"thresholds"`          // Overrides cloud thresholds
+	Services           []ServiceConfig  `yaml:"services"`
+	Probes             []ProbeConfig    `yaml:"probes"` // Empty: switch-gate for switch-gate upstreams, prometheus otherwise
+	Discovered         bool             `yaml:"-"`      // Created from Prometheus targets
+}
+
+// ProbeConfig declares a health probe for a server
+type ProbeConfig struct {
+	Kind        string `yaml:"kind" json:"kind"`               // prometheus, switch-gate, ssh-command, http, tcp
+	Name        string `yaml:"name" json:"name"`               // Display name of the check (ssh-command, http, tcp)
+	URL         string `yaml:"url" json:"url"`                 // http: URL, assertions as in external checks
+	Address     string `yaml:"address" json:"address"`         // tcp: "host:port"
+	Command     string `yaml:"command" json:"command"`         // ssh-command: command to run on the server
+	Expect      string `yaml:"expect" json:"expect"`           // ssh-command: expected output substring
+	Criticality string `yaml:"criticality" json:"criticality"` // as for services (default warning)
+}
+
+// Built-in probe kinds
+const (
+	ProbePrometheus = "prometheus"
+	ProbeSwitchGate = "switch-gate"
+	ProbeSSHCommand = "ssh-command"
+	ProbeHTTP       = "http"
+	ProbeTCP        = "tcp"
+)
+
+// ExternalCheckURLs returns all external checks of the server
+// (external_check first, then external_checks)
+func (s *ServerConfig) ExternalCheckURLs() []string {
+	var checks []string
+	if s.ExternalCheck != "" {
+		checks = append(checks, s.ExternalCheck)
+	}
+	for _, check := range s.ExternalChecks {
+		if check != "" {
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+// ExporterType returns the exporter type of the server (default node_exporter)
+func (s *ServerConfig) ExporterType() string {
+	if s.Exporter == "" {
+		return ExporterNode
+	}
+	return s.Exporter
+}
+
+// IsKnownExporter reports whether an exporter type is built in or defined in
+// infrastructure.exporters
+func (c *Config) IsKnownExporter(name string) bool {
+	switch name {
+	case ExporterNode, ExporterWindows, ExporterCAdvisor:
+		return true
+	}
+	_, ok := c.Infrastructure.Exporters[name]
+	return ok
 }
 
 // ServiceConfig represents a service running on a server
 type ServiceConfig struct {
 	Name string `yaml:"name"` // "Nginx"
 	Job  string `yaml:"job"`  // Prometheus job name (optional)
-	Port int    `yaml:"port"` // Port number (optional, for display)
+	Port int    `yaml:"port"` // Port number (optional, checked locally on switch-gate servers)
+	Unit string `yaml:"unit"` // systemd unit on switch-gate servers (empty: no systemd check)
+
+	// Criticality decides what a down service means for the server:
+	// "critical" - server down, "warning" (default) - server degraded, "info" - ignored
+	Criticality string `yaml:"criticality"`
+
+	Discovered bool `yaml:"-"` // Attached from Prometheus targets
+}
+
+// Service criticality levels
+const (
+	CriticalityCritical = "critical"
+	CriticalityWarning  = "warning"
+	CriticalityInfo     = "info"
+)
+
+// ThresholdsConfig defines warn and critical levels for server health
+// Zero values inherit from the parent block (server → cloud → global),
+// negative values disable the check
+type ThresholdsConfig struct {
+	CPU         Threshold         `yaml:"cpu" json:"cpu"`                   // percent
+	Memory      Threshold         `yaml:"memory" json:"memory"`             // percent
+	Disk        Threshold         `yaml:"disk" json:"disk"`                 // percent
+	UptimeReset DurationThreshold `yaml:"uptime_reset" json:"uptime_reset"` // uptime below value (recent reboot)
+	TLSExpiry   Threshold         `yaml:"tls_expiry" json:"tls_expiry"`     // days until certificate expiry below value
+	DiskFill    DurationThreshold `yaml:"disk_fill" json:"disk_fill"`       // predicted time until a filesystem is full below value
+}
+
+// Threshold defines warn (degraded) and critical (down) levels for a number
+// (a percentage, or days for TLSExpiry)
+type Threshold struct {
+	Warn     float64 `yaml:"warn" json:"warn"`
+	Critical float64 `yaml:"critical" json:"critical"`
+}
+
+// DurationThreshold defines warn (degraded) and critical (down) levels for a duration
+type DurationThreshold struct {
+	Warn     time.Duration `yaml:"warn"`
+	Critical time.Duration `yaml:"critical"`
+}
+
+// UnmarshalJSON parses durations from strings like "1h" (S3 metadata)
+func (t *DurationThreshold) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Warn     string `json:"warn"`
+		Critical string `json:"critical"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var err error
+	if raw.Warn != "" {
+		if t.Warn, err = time.ParseDuration(raw.Warn); err != nil {
+			return fmt.Errorf("warn: %w", err)
+		}
+	}
+	if raw.Critical != "" {
+		if t.Critical, err = time.ParseDuration(raw.Critical); err != nil {
+			return fmt.Errorf("critical: %w", err)
+		}
+	}
+	return nil
+}
+
+// Merge returns thresholds with non-zero values from override applied
+func (t ThresholdsConfig) Merge(override ThresholdsConfig) ThresholdsConfig {
+	t.CPU = t.CPU.merge(override.CPU)
+	t.Memory = t.Memory.merge(override.Memory)
+	t.Disk = t.Disk.merge(override.Disk)
+	t.TLSExpiry = t.TLSExpiry.merge(override.TLSExpiry)
+	t.UptimeReset = t.UptimeReset.merge(override.UptimeReset)
+	t.DiskFill = t.DiskFill.merge(override.DiskFill)
+	return t
+}
+
+// merge returns threshold with non-zero values from override applied
+func (t DurationThreshold) merge(override DurationThreshold) DurationThreshold {
+	if override.Warn != 0 {
+		t.Warn = override.Warn
+	}
+	if override.Critical != 0 {
+		t.Critical = override.Critical
+	}
+	return t
+}
+
+// merge returns threshold with non-zero values from override applied
+func (t Threshold) merge(override Threshold) Threshold {
+	if override.Warn != 0 {
+		t.Warn = override.Warn
+	}
+	if override.Critical != 0 {
+		t.Critical = override.Critical
+	}
+	return t
 }
 
 // WebhooksConfig configures the webhook receiver
 type WebhooksConfig struct {
-	Enabled bool   `yaml:"enabled"`
-	Listen  string `yaml:"listen"`
-	Secret  string `yaml:"secret"`
+	Enabled      bool            `yaml:"enabled"`
+	Listen       string          `yaml:"listen"`
+	Secret       string          `yaml:"secret"`
+	MetricsToken string          `yaml:"metrics_token"` // Bearer token required for /metrics (optional)
+	BasicAuth    BasicAuthConfig `yaml:"basic_auth"`    // Accepted instead of the secret header (optional)
+	Routes       []WebhookRoute  `yaml:"routes"`        // Template-driven routes (Grafana, CI, backups...)
+}
+
+// WebhookRoute renders JSON posted to a path through a message template
+type WebhookRoute struct {
+	Name     string            `yaml:"name"`     // Route name for logs and metrics
+	Path     string            `yaml:"path"`     // e.g. "/webhook/grafana"
+	Secret   string            `yaml:"secret"`   // X-Webhook-Secret for this route (default: webhooks.secret)
+	Fields   map[string]string `yaml:"fields"`   // Template field -> JSON path ("alerts.0.labels.alertname")
+	Template string            `yaml:"template"` // Go text/template producing Telegram HTML
+}
+
+// BasicAuthConfig holds HTTP basic auth credentials
+type BasicAuthConfig struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
 }
 
 // Upstream represents a VPS upstream server
@@ -66,8 +436,34 @@ type Upstream struct {
 }
 
 type TelegramConfig struct {
-	Token          string  `yaml:"token"`
-	AllowedChatIDs []int64 `yaml:"allowed_chat_ids"`
+	Token          string            `yaml:"token"`
+	AllowedChatIDs []int64           `yaml:"allowed_chat_ids"`
+	AdminUserIDs   []int64           `yaml:"admin_user_ids"` // Users with the admin role (shorthand for users)
+	Users          map[int64]string  `yaml:"users"`          // User ID -> role
+	DefaultRole    string            `yaml:"default_role"`   // Role of unlisted users (default: admin if no users are listed, viewer otherwise)
+	Permissions    map[string]string `yaml:"permissions"`    // Minimum role overrides: "/command", "category:action" or "category:*"
+	Confirm        ConfirmConfig     `yaml:"confirm"`        // Two-step confirmation of risky buttons
+}
+
+// ConfirmConfig configures two-step confirmation of inline buttons
+type ConfirmConfig struct {
+	Actions []string      `yaml:"actions"` // Callbacks to confirm: "category:action" or "category:*" (default edge:full, restart:*)
+	Timeout time.Duration `yaml:"timeout"` // Time to confirm (default 30s)
+}
+
+// Telegram user roles, from least to most privileged
+const (
+	RoleViewer   = "viewer"   // Read-only views
+	RoleOperator = "operator" // Switch modes, restart switch-gate, silence alerts
+	RoleAdmin    = "admin"    // Everything, including ad-hoc PromQL
+)
+
+// roleRanks orders roles by privilege
+var roleRanks = map[string]int{RoleViewer: 1, RoleOperator: 2, RoleAdmin: 3}
+
+// RoleAllows reports whether a role grants the permissions of a required role
+func RoleAllows(role, required string) bool {
+	return roleRanks[role] >= roleRanks[required]
 }
 
 type EdgeConfig struct {
@@ -128,12 +524,38 @@ func (c *Config) MergeS3Metadata(metadata *S3Metadata) {
 	}
 
 	// Replace infrastructure clouds (S3 takes precedence)
-	if len(metadata.Clouds) > 0 {
-		c.Infrastructure.Clouds = metadata.Clouds
+	if clouds := c.validS3Clouds(metadata.Clouds); len(clouds) > 0 {
+		c.Infrastructure.Clouds = clouds
 		c.Infrastructure.Enabled = true
 	}
 }
 
+// validS3Clouds validates S3 clouds like YAML clouds and sets their defaults
+// Invalid servers, and clouds with an unknown data source, are skipped with a
+// warning instead of stopping the bot
+func (c *Config) validS3Clouds(clouds []CloudConfig) []CloudConfig {
+	valid := make([]CloudConfig, 0, len(clouds))
+	for _, cloud := range clouds {
+		if cloud.Datasource != "" && c.GetDatasource(cloud.Datasource) == nil {
+			log.Printf("Warning: S3 cloud %s skipped: unknown datasource %s", cloud.Name, cloud.Datasource)
+			continue
+		}
+		setCloudDefaults(&cloud)
+
+		servers := make([]ServerConfig, 0, len(cloud.Servers))
+		for _, server := range cloud.Servers {
+			if err := c.validateServer(&server); err != nil {
+				log.Printf("Warning: S3 server skipped: %v", err)
+				continue
+			}
+			servers = append(servers, server)
+		}
+		cloud.Servers = servers
+		valid = append(valid, cloud)
+	}
+	return valid
+}
+
 // ValidateRuntime checks required fields after S3 merge
 // Call this after MergeS3Metadata to ensure we have valid config
 func (c *Config) ValidateRuntime() error {
@@ -143,6 +565,51 @@ func (c *Config) ValidateRuntime() error {
 	if len(c.Upstreams) == 0 {
 		return fmt.Errorf("at least one upstream is required (configure in YAML or enable S3)")
 	}
+	if err := c.validateCloudDatasources(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validateTelegram checks user roles, permission overrides and confirmed actions
+// and sets their defaults
+// Without users and admin_user_ids everyone in allowed chats is an admin, as
+// before roles existed
+func (c *Config) validateTelegram() error {
+	tg := &c.Telegram
+	if tg.DefaultRole == "" {
+		tg.DefaultRole = RoleViewer
+		if len(tg.Users) == 0 && len(tg.AdminUserIDs) == 0 {
+			tg.DefaultRole = RoleAdmin
+		}
+	}
+	if _, ok := roleRanks[tg.DefaultRole]; !ok {
+		return fmt.Errorf("telegram.default_role: unknown role %q", tg.DefaultRole)
+	}
+	for id, role := range tg.Users {
+		if _, ok := roleRanks[role]; !ok {
+			return fmt.Errorf("telegram.users[%d]: unknown role %q", id, role)
+		}
+	}
+	if tg.Confirm.Actions == nil {
+		tg.Confirm.Actions = []string{"edge:full", "restart:*"}
+	}
+	for _, key := range tg.Confirm.Actions {
+		if !strings.Contains(key, ":") {
+			return fmt.Errorf("telegram.confirm.actions: invalid key %q (expected \"category:action\")", key)
+		}
+	}
+	if tg.Confirm.Timeout <= 0 {
+		tg.Confirm.Timeout = 30 * time.Second
+	}
+	for key, role := range tg.Permissions {
+		if !strings.HasPrefix(key, "/") && !strings.Contains(key, ":") {
+			return fmt.Errorf("telegram.permissions: invalid key %q (expected \"/command\" or \"category:action\")", key)
+		}
+		if _, ok := roleRanks[role]; !ok {
+			return fmt.Errorf("telegram.permissions[%s]: unknown role %q", key, role)
+		}
+	}
 	return nil
 }
 
@@ -154,6 +621,9 @@ func (c *Config) Validate() error {
 	if len(c.Telegram.AllowedChatIDs) == 0 {
 		return fmt.Errorf("telegram.allowed_chat_ids is required")
 	}
+	if err := c.validateTelegram(); err != nil {
+		return err
+	}
 	// Edge and upstreams are validated after S3 merge (in ValidateAfterMerge)
 	if c.Edge.Name == "" {
 		c.Edge.Name = "Edge Gateway"
@@ -164,10 +634,33 @@ func (c *Config) Validate() error {
 	if c.Logging.Level == "" {
 		c.Logging.Level = "info"
 	}
+	if c.Storage.DataDir == "" {
+		c.Storage.DataDir = "/var/lib/scinfra-bot"
+	}
+	if c.Storage.IncidentRetention == 0 {
+		c.Storage.IncidentRetention = 90 * 24 * time.Hour
+	}
 	// Webhooks defaults
 	if c.Webhooks.Listen == "" {
 		c.Webhooks.Listen = "0.0.0.0:8080"
 	}
+	if c.Webhooks.Enabled && c.Webhooks.Secret == "" && c.Webhooks.BasicAuth.Username == "" {
+		return fmt.Errorf("webhooks.secret or webhooks.basic_auth is required when webhooks.enabled is true")
+	}
+	paths := map[string]bool{"/webhook/switch-gate": true, "/webhook/alertmanager": true, "/health": true, "/metrics": true}
+	for i, route := range c.Webhooks.Routes {
+		switch {
+		case route.Name == "":
+			return fmt.Errorf("webhooks.routes[%d]: name is required", i)
+		case !strings.HasPrefix(route.Path, "/"):
+			return fmt.Errorf("webhooks.routes[%d]: path must start with /", i)
+		case paths[route.Path]:
+			return fmt.Errorf("webhooks.routes[%d]: path %s is already in use", i, route.Path)
+		case route.Template == "":
+			return fmt.Errorf("webhooks.routes[%d]: template is required", i)
+		}
+		paths[route.Path] = true
+	}
 	// S3 validation
 	if c.S3.Enabled {
 		if c.S3.Bucket == "" {
@@ -203,19 +696,98 @@ func (c *Config) Validate() error {
 	if c.Infrastructure.PrometheusURL == "" {
 		c.Infrastructure.PrometheusURL = "http://localhost:9090"
 	}
+	if err := c.validateDatasources(); err != nil {
+		return err
+	}
+	if err := c.validatePromQL(); err != nil {
+		return err
+	}
+	if c.Infrastructure.Discovery.Cloud == "" {
+		c.Infrastructure.Discovery.Cloud = "Discovered"
+	}
+	if c.Infrastructure.Discovery.IgnoreJobs == nil {
+		c.Infrastructure.Discovery.IgnoreJobs = []string{"prometheus"}
+	}
+	if c.Infrastructure.ProbeConcurrency <= 0 {
+		c.Infrastructure.ProbeConcurrency = 4
+	}
+	if c.Infrastructure.ProbeTimeout == 0 {
+		c.Infrastructure.ProbeTimeout = 30 * time.Second
+	}
+	// Set defaults for global thresholds
+	th := &c.Infrastructure.Thresholds
+	if th.CPU.Warn == 0 {
+		th.CPU.Warn = 80
+	}
+	if th.Memory.Warn == 0 {
+		th.Memory.Warn = 85
+	}
+	if th.Disk.Warn == 0 {
+		th.Disk.Warn = 85
+	}
+	if th.TLSExpiry.Warn == 0 {
+		th.TLSExpiry.Warn = 14
+	}
+	if th.TLSExpiry.Critical == 0 {
+		th.TLSExpiry.Critical = 3
+	}
+	if th.DiskFill.Warn == 0 {
+		th.DiskFill.Warn = 72 * time.Hour
+	}
+	// Validate maintenance windows
+	for i, w := range c.Infrastructure.Maintenance {
+		switch {
+		case w.Target == "":
+			return fmt.Errorf("infrastructure.maintenance[%d]: target is required", i)
+		case w.Duration <= 0:
+			return fmt.Errorf("infrastructure.maintenance[%d]: duration is required", i)
+		case w.Start.IsZero() == (w.Weekly == ""):
+			return fmt.Errorf("infrastructure.maintenance[%d]: set either start or weekly", i)
+		}
+		if w.Weekly != "" {
+			if _, _, err := parseWeekly(w.Weekly); err != nil {
+				return fmt.Errorf("infrastructure.maintenance[%d]: %w", i, err)
+			}
+		}
+	}
+	// Validate SLA report
+	if report := &c.Infrastructure.SLAReport; report.Enabled {
+		if report.Schedule == "" {
+			report.Schedule = "mon 09:00"
+		}
+		kind, _, _, err := parseSchedule(report.Schedule)
+		if err != nil {
+			return fmt.Errorf("infrastructure.sla_report: %w", err)
+		}
+		if report.Period == "" {
+			report.Period = map[string]string{"daily": PeriodDay, "weekly": PeriodWeek, "monthly": PeriodMonth}[kind]
+		}
+		switch report.Period {
+		case PeriodDay, PeriodWeek, PeriodMonth:
+		default:
+			return fmt.Errorf("infrastructure.sla_report: invalid period %q (day, week or month)", report.Period)
+		}
+	}
+	// Set defaults for alerts
+	if c.Infrastructure.Alerts.Interval == 0 {
+		c.Infrastructure.Alerts.Interval = 60 * time.Second
+	}
+	if c.Infrastructure.Alerts.MinDuration == 0 {
+		c.Infrastructure.Alerts.MinDuration = 2 * time.Minute
+	}
+	if c.Infrastructure.Alerts.FlapWindow == 0 {
+		c.Infrastructure.Alerts.FlapWindow = 30 * time.Minute
+	}
+	if c.Infrastructure.Alerts.FlapThreshold == 0 {
+		c.Infrastructure.Alerts.FlapThreshold = 4
+	}
 	// Set defaults for servers
 	for i := range c.Infrastructure.Clouds {
 		cloud := &c.Infrastructure.Clouds[i]
-		if cloud.Icon == "" {
-			cloud.Icon = "☁️"
-		}
+		setCloudDefaults(cloud)
 		for j := range cloud.Servers {
-			server := &cloud.Servers[j]
-			if server.Name == "" {
-				server.Name = server.ID
-			}
-			if server.Icon == "" {
-				server.Icon = "🖥️"
+			if err := c.validateServer(&cloud.Servers[j]); err != nil {
+				return err
 			}
 		}
 	}
@@ -223,6 +795,111 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// setCloudDefaults sets the defaults of a cloud
+func setCloudDefaults(cloud *CloudConfig) {
+	if cloud.Icon == "" {
+		cloud.Icon = "☁️"
+	}
+}
+
+// validateServer sets the defaults of a server and checks its exporter,
+// service criticalities and probes
+func (c *Config) validateServer(server *ServerConfig) error {
+	if server.Name == "" {
+		server.Name = server.ID
+	}
+	if server.Icon == "" {
+		server.Icon = "🖥️"
+	}
+	if !c.IsKnownExporter(server.ExporterType()) {
+		return fmt.Errorf("server %s: unknown exporter %q", server.ID, server.Exporter)
+	}
+	for _, svc := range server.Services {
+		switch svc.Criticality {
+		case "", CriticalityCritical, CriticalityWarning, CriticalityInfo:
+		default:
+			return fmt.Errorf("server %s: service %s: invalid criticality %q (critical, warning, info)",
+				server.ID, svc.Name, svc.Criticality)
+		}
+	}
+	for k, probe := range server.Probes {
+		if probe.Kind == "" {
+			return fmt.Errorf("server %s: probe %d: kind is required", server.ID, k+1)
+		}
+		switch probe.Criticality {
+		case "", CriticalityCritical, CriticalityWarning, CriticalityInfo:
+		default:
+			return fmt.Errorf("server %s: probe %s: invalid criticality %q (critical, warning, info)",
+				server.ID, probe.Kind, probe.Criticality)
+		}
+	}
+	return nil
+}
+
+// validateDatasources sets data source defaults, adds the default data source
+// from prometheus_url and checks data source fields and cloud references
+func (c *Config) validateDatasources() error {
+	infra := &c.Infrastructure
+	names := make(map[string]bool)
+	for i := range infra.Datasources {
+		ds := &infra.Datasources[i]
+		switch {
+		case ds.Name == "":
+			return fmt.Errorf("infrastructure.datasources[%d]: name is required", i)
+		case names[ds.Name]:
+			return fmt.Errorf("infrastructure.datasources[%d]: duplicate name %s", i, ds.Name)
+		case ds.URL == "":
+			return fmt.Errorf("datasource %s: url is required", ds.Name)
+		case ds.BearerToken != "" && ds.BasicAuth.Username != "":
+			return fmt.Errorf("datasource %s: set either basic_auth or bearer_token", ds.Name)
+		case (ds.CertFile == "") != (ds.KeyFile == ""):
+			return fmt.Errorf("datasource %s: cert_file and key_file must be set together", ds.Name)
+		}
+		ds.URL = strings.TrimRight(ds.URL, "/")
+		if ds.Timeout == 0 {
+			ds.Timeout = 10 * time.Second
+		}
+		names[ds.Name] = true
+	}
+	if !names[DefaultDatasource] {
+		infra.Datasources = append(infra.Datasources, DatasourceConfig{
+			Name:    DefaultDatasource,
+			URL:     strings.TrimRight(infra.PrometheusURL, "/"),
+			Timeout: 10 * time.Second,
+		})
+	}
+	return c.validateCloudDatasources()
+}
+
+// validatePromQL sets the result size default and checks saved queries
+func (c *Config) validatePromQL() error {
+	promql := &c.Infrastructure.PromQL
+	if promql.MaxRows <= 0 {
+		promql.MaxRows = 20
+	}
+	for name, q := range promql.Queries {
+		switch {
+		case name == "" || strings.ContainsAny(name, " \t\n"):
+			return fmt.Errorf("infrastructure.promql.queries: invalid name %q", name)
+		case q.Query == "":
+			return fmt.Errorf("infrastructure.promql.queries.%s: query is required", name)
+		case q.Datasource != "" && c.GetDatasource(q.Datasource) == nil:
+			return fmt.Errorf("infrastructure.promql.queries.%s: unknown datasource %s", name, q.Datasource)
+		}
+	}
+	return nil
+}
+
+// validateCloudDatasources checks that clouds reference defined data sources
+func (c *Config) validateCloudDatasources() error {
+	for _, cloud := range c.Infrastructure.Clouds {
+		if cloud.Datasource != "" && c.GetDatasource(cloud.Datasource) == nil {
+			return fmt.Errorf("cloud %s: unknown datasource %s", cloud.Name, cloud.Datasource)
+		}
+	}
+	return nil
+}
+
 // IsValidUpstream checks if upstream name is in the list
 func (c *Config) IsValidUpstream(name string) bool {
 	_, ok := c.Upstreams[name]
@@ -264,6 +941,20 @@ func (c *Config) IsAllowedChat(chatID int64) bool {
 	return false
 }
 
+// UserRole returns the role of a Telegram user: admin_user_ids, then users,
+// then default_role
+func (c *Config) UserRole(userID int64) string {
+	for _, id := range c.Telegram.AdminUserIDs {
+		if id == userID {
+			return RoleAdmin
+		}
+	}
+	if role, ok := c.Telegram.Users[userID]; ok {
+		return role
+	}
+	return c.Telegram.DefaultRole
+}
+
 // GetUpstreamDisplayName returns display name for upstream
 func (c *Config) GetUpstreamDisplayName(name string) string {
 	if u, ok := c.Upstreams[name]; ok && u.Name != "" {
@@ -295,6 +986,41 @@ func (c *Config) GetServer(serverID string) *ServerConfig {
 	return nil
 }
 
+// GetServerByIP returns server config by IP address
+// Returns nil if not found
+func (c *Config) GetServerByIP(ip string) *ServerConfig {
+	for i := range c.Infrastructure.Clouds {
+		for j := range c.Infrastructure.Clouds[i].Servers {
+			if c.Infrastructure.Clouds[i].Servers[j].IP == ip {
+				return &c.Infrastructure.Clouds[i].Servers[j]
+			}
+		}
+	}
+	return nil
+}
+
+// GetServerByInstance finds a server by a Prometheus instance label
+// ("host:port" or "host"), matching prometheus_instance, ID, name or IP
+// Returns nil if not found
+func (c *Config) GetServerByInstance(instance string) *ServerConfig {
+	host := instance
+	if h, _, err := net.SplitHostPort(instance); err == nil {
+		host = h
+	}
+	for i := range c.Infrastructure.Clouds {
+		for j := range c.Infrastructure.Clouds[i].Servers {
+			server := &c.Infrastructure.Clouds[i].Servers[j]
+			switch {
+			case server.PrometheusInstance != "" && (server.PrometheusInstance == instance || server.PrometheusInstance == host):
+				return server
+			case server.ID == host, server.Name == host, server.IP != "" && server.IP == host:
+				return server
+			}
+		}
+	}
+	return nil
+}
+
 // GetServerCloud returns cloud name for a server
 func (c *Config) GetServerCloud(serverID string) string {
 	for i := range c.Infrastructure.Clouds {
@@ -307,6 +1033,28 @@ func (c *Config) GetServerCloud(serverID string) string {
 	return ""
 }
 
+// GetDatasource returns a data source by name
+func (c *Config) GetDatasource(name string) *DatasourceConfig {
+	for i := range c.Infrastructure.Datasources {
+		if c.Infrastructure.Datasources[i].Name == name {
+			return &c.Infrastructure.Datasources[i]
+		}
+	}
+	return nil
+}
+
+// GetServerDatasource returns the data source name of a server's cloud
+func (c *Config) GetServerDatasource(serverID string) string {
+	for _, cloud := range c.Infrastructure.Clouds {
+		for _, server := range cloud.Servers {
+			if server.ID == serverID && cloud.Datasource != "" {
+				return cloud.Datasource
+			}
+		}
+	}
+	return DefaultDatasource
+}
+
 // GetAllServers returns all server configs
 func (c *Config) GetAllServers() []ServerConfig {
 	var servers []ServerConfig
@@ -316,6 +1064,21 @@ func (c *Config) GetAllServers() []ServerConfig {
 	return servers
 }
 
+// GetServerThresholds returns effective thresholds for a server
+// (global thresholds merged with cloud and server overrides)
+func (c *Config) GetServerThresholds(serverID string) ThresholdsConfig {
+	thresholds := c.Infrastructure.Thresholds
+	for i := range c.Infrastructure.Clouds {
+		cloud := &c.Infrastructure.Clouds[i]
+		for j := range cloud.Servers {
+			if cloud.Servers[j].ID == serverID {
+				return thresholds.Merge(cloud.Thresholds).Merge(cloud.Servers[j].Thresholds)
+			}
+		}
+	}
+	return thresholds
+}
+
 // IsInfrastructureEnabled returns true if infrastructure monitoring is configured
 func (c *Config) IsInfrastructureEnabled() bool {
 	return c.Infrastructure.Enabled && len(c.Infrastructure.Clouds) > 0
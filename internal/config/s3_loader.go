@@ -40,25 +40,33 @@ type ProviderMetadata struct {
 
 	// Cloud info
 	Cloud struct {
-		Name string `json:"name"`
-		Icon string `json:"icon"`
+		Name       string           `json:"name"`
+		Icon       string           `json:"icon"`
+		Thresholds ThresholdsConfig `json:"thresholds"`
+		Datasource string           `json:"datasource"` // Name of a data source defined in YAML
 	} `json:"cloud"`
 
 	// Servers
 	Servers []struct {
-		ID                 string `json:"id"`                  // Unique identifier (from cloud provider)
-		ServerName         string `json:"server_name"`         // Technical name from cloud provider
-		Name               string `json:"name"`                // Display name (falls back to server_name)
-		PrometheusInstance string `json:"prometheus_instance"` // Instance label for Prometheus queries
-		Icon               string `json:"icon"`
-		IP                 string `json:"ip"`
-		ExternalIP         string `json:"external_ip"`
-		ExternalCheck      string `json:"external_check"`
+		ID                 string           `json:"id"`                  // Unique identifier (from cloud provider)
+		ServerName         string           `json:"server_name"`         // Technical name from cloud provider
+		Name               string           `json:"name"`                // Display name (falls back to server_name)
+		PrometheusInstance string           `json:"prometheus_instance"` // Instance label for Prometheus queries
+		Exporter           string           `json:"exporter"`            // Exporter type for Prometheus queries
+		Icon               string           `json:"icon"`
+		IP                 string           `json:"ip"`
+		ExternalIP         string           `json:"external_ip"`
+		ExternalCheck      string           `json:"external_check"`
+		ExternalChecks     []string         `json:"external_checks"`
+		Thresholds         ThresholdsConfig `json:"thresholds"`
 		Services           []struct {
-			Name string `json:"name"`
-			Job  string `json:"job"`
-			Port int    `json:"port"`
+			Name        string `json:"name"`
+			Job         string `json:"job"`
+			Port        int    `json:"port"`
+			Unit        string `json:"unit"`
+			Criticality string `json:"criticality"`
 		} `json:"services"`
+		Probes []ProbeConfig `json:"probes"` // ssh-command probes are rejected (local YAML only)
 	} `json:"servers"`
 
 	// Edge config (optional, for main cloud provider)
@@ -202,18 +210,22 @@ func (l *S3Loader) processMetadata(pm *ProviderMetadata, metadata *S3Metadata) {
 	// Cloud with servers
 	if pm.Cloud.Name != "" && len(pm.Servers) > 0 {
 		cloud := CloudConfig{
-			Name:    pm.Cloud.Name,
-			Icon:    pm.Cloud.Icon,
-			Servers: make([]ServerConfig, 0, len(pm.Servers)),
+			Name:       pm.Cloud.Name,
+			Icon:       pm.Cloud.Icon,
+			Thresholds: pm.Cloud.Thresholds,
+			Datasource: pm.Cloud.Datasource,
+			Servers:    make([]ServerConfig, 0, len(pm.Servers)),
 		}
 
 		for _, s := range pm.Servers {
 			services := make([]ServiceConfig, 0, len(s.Services))
 			for _, svc := range s.Services {
 				services = append(services, ServiceConfig{
-					Name: svc.Name,
-					Job:  svc.Job,
-					Port: svc.Port,
+					Name:        svc.Name,
+					Job:         svc.Job,
+					Port:        svc.Port,
+					Unit:        svc.Unit,
+					Criticality: svc.Criticality,
 				})
 			}
 
@@ -235,11 +247,29 @@ func (l *S3Loader) processMetadata(pm *ProviderMetadata, metadata *S3Metadata) {
 				Icon:               s.Icon,
 				IP:                 s.IP,
 				ExternalCheck:      s.ExternalCheck,
+				ExternalChecks:     s.ExternalChecks,
 				PrometheusInstance: promInstance,
+				Exporter:           s.Exporter,
+				Thresholds:         s.Thresholds,
 				Services:           services,
+				Probes:             s3Probes(s.ID, s.Probes),
 			})
 		}
 
 		metadata.Clouds = append(metadata.Clouds, cloud)
 	}
 }
+
+// s3Probes returns the probes of an S3 server without ssh-command probes:
+// they run shell commands on the VPS, so only local YAML may declare them
+func s3Probes(serverID string, probes []ProbeConfig) []ProbeConfig {
+	allowed := make([]ProbeConfig, 0, len(probes))
+	for _, probe := range probes {
+		if probe.Kind == ProbeSSHCommand {
+			log.Printf("Warning: server %s: ssh-command probe ignored (not allowed in S3 metadata)", serverID)
+			continue
+		}
+		allowed = append(allowed, probe)
+	}
+	return allowed
+}
@@ -0,0 +1,303 @@
+// Package discovery matches Prometheus scrape targets to configured servers:
+// it attaches scraped jobs as services, creates servers for unknown exporter
+// instances and reports drift between declared services and scraped jobs
+package discovery
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/config"
+	"github.com/scinfra-pro/scinfra-bot/internal/health"
+)
+
+// targetsTTL is how long scrape targets are cached for drift reports
+const targetsTTL = 60 * time.Second
+
+// Target is a scrape target of a data source
+type Target struct {
+	Datasource string
+	Job        string
+	Instance   string
+	Health     string // "up", "down" or "unknown"
+}
+
+// Result summarizes what Apply changed in the config
+type Result struct {
+	Attached int // services attached to configured servers
+	Created  int // servers created in the discovery cloud
+}
+
+// Drift is the difference between the declared services of a server and the
+// jobs Prometheus scrapes for it
+type Drift struct {
+	ServerID   string
+	Missing    []string // declared jobs without a scrape target
+	Undeclared []string // scraped jobs without a declared service
+}
+
+// Discoverer reads scrape targets from the Prometheus data sources of a health checker
+// Discoverer is safe for concurrent use
+type Discoverer struct {
+	config       *config.Config
+	checker      *health.Checker
+	exporterJobs map[string]string // exporter job -> exporter type
+	ignore       map[string]bool   // ignored jobs
+
+	mu      sync.Mutex
+	targets []Target
+	fetched time.Time
+}
+
+// New creates a discoverer for the data sources used by clouds
+// Exporter jobs (e.g. "node") describe the host itself and are never attached as services
+func New(cfg *config.Config, checker *health.Checker) *Discoverer {
+	ignore := make(map[string]bool)
+	for _, job := range cfg.Infrastructure.Discovery.IgnoreJobs {
+		ignore[job] = true
+	}
+	return &Discoverer{
+		config:       cfg,
+		checker:      checker,
+		exporterJobs: checker.ExporterJobs(),
+		ignore:       ignore,
+	}
+}
+
+// Targets returns the active scrape targets of all data sources (cached for targetsTTL)
+// Returns an error only if no data source could be read
+func (d *Discoverer) Targets() ([]Target, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.targets != nil && time.Since(d.fetched) < targetsTTL {
+		return d.targets, nil
+	}
+
+	names := d.checker.UsedDatasources()
+	targets := []Target{}
+	var errs []error
+	for _, name := range names {
+		client, err := d.checker.Datasource(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		list, err := client.Targets()
+		if err != nil {
+			log.Printf("Discovery: datasource %s: %v", name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		for _, t := range list {
+			if t.Job() == "" || t.Instance() == "" {
+				continue
+			}
+			targets = append(targets, Target{Datasource: name, Job: t.Job(), Instance: t.Instance(), Health: t.Health})
+		}
+	}
+	if len(errs) == len(names) && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	d.targets = targets
+	d.fetched = time.Now()
+	return targets, nil
+}
+
+// Apply attaches scraped jobs to the configured servers as services and, with
+// create_servers, adds servers for exporter instances that match no server
+// Apply changes the config and must be called before it is used concurrently
+func (d *Discoverer) Apply() (Result, error) {
+	var result Result
+	targets, err := d.Targets()
+	if err != nil {
+		return result, err
+	}
+
+	unmatched := make(map[string][]Target) // "datasource/host" -> targets
+	var order []string
+	for _, t := range targets {
+		if d.ignore[t.Job] {
+			continue
+		}
+		if d.config.GetServerByInstance(t.Instance) == nil {
+			key := t.Datasource + "/" + instanceHost(t.Instance)
+			if _, ok := unmatched[key]; !ok {
+				order = append(order, key)
+			}
+			unmatched[key] = append(unmatched[key], t)
+			continue
+		}
+		server := d.serverFor(t)
+		if server != nil && d.isService(t.Job) && !hasJob(server.Services, t.Job) {
+			server.Services = append(server.Services, config.ServiceConfig{Name: t.Job, Job: t.Job, Discovered: true})
+			result.Attached++
+		}
+	}
+
+	if !d.config.Infrastructure.Discovery.CreateServers {
+		return result, nil
+	}
+	for _, key := range order {
+		if server := d.newServer(unmatched[key]); server != nil {
+			d.addServer(unmatched[key][0].Datasource, *server)
+			result.Created++
+		}
+	}
+	return result, nil
+}
+
+// Drift compares declared services with the scraped jobs of each configured server
+// Only servers with differences are returned, in config order
+func (d *Discoverer) Drift() ([]Drift, error) {
+	targets, err := d.Targets()
+	if err != nil {
+		return nil, err
+	}
+
+	scraped := make(map[string]map[string]bool) // server ID -> jobs
+	for _, t := range targets {
+		if d.ignore[t.Job] || !d.isService(t.Job) {
+			continue
+		}
+		if server := d.serverFor(t); server != nil {
+			if scraped[server.ID] == nil {
+				scraped[server.ID] = make(map[string]bool)
+			}
+			scraped[server.ID][t.Job] = true
+		}
+	}
+
+	var drifts []Drift
+	for _, cloud := range d.config.Infrastructure.Clouds {
+		for _, server := range cloud.Servers {
+			if server.Discovered {
+				continue // nothing declared
+			}
+			drift := Drift{ServerID: server.ID}
+			declared := make(map[string]bool)
+			for _, svc := range server.Services {
+				if svc.Job == "" || svc.Discovered {
+					continue
+				}
+				declared[svc.Job] = true
+				if !scraped[server.ID][svc.Job] {
+					drift.Missing = append(drift.Missing, svc.Job)
+				}
+			}
+			for job := range scraped[server.ID] {
+				if !declared[job] {
+					drift.Undeclared = append(drift.Undeclared, job)
+				}
+			}
+			if len(drift.Missing) > 0 || len(drift.Undeclared) > 0 {
+				sort.Strings(drift.Missing)
+				sort.Strings(drift.Undeclared)
+				drifts = append(drifts, drift)
+			}
+		}
+	}
+	return drifts, nil
+}
+
+// serverFor returns the Prometheus-monitored server of a target's instance in
+// a cloud using the target's data source, or nil
+func (d *Discoverer) serverFor(t Target) *config.ServerConfig {
+	server := d.config.GetServerByInstance(t.Instance)
+	if server == nil || d.config.GetServerDatasource(server.ID) != t.Datasource {
+		return nil
+	}
+	if _, err := d.checker.PrometheusTarget(server.ID); err != nil {
+		return nil // e.g. switch-gate servers, whose services are checked over SSH
+	}
+	return server
+}
+
+// isService reports whether a job is a service (not a host exporter)
+func (d *Discoverer) isService(job string) bool {
+	_, exporter := d.exporterJobs[job]
+	return !exporter
+}
+
+// newServer creates a server from the targets of one instance host, or nil if
+// no target is a known exporter or the ID is already taken
+func (d *Discoverer) newServer(targets []Target) *config.ServerConfig {
+	host := instanceHost(targets[0].Instance)
+	if d.config.GetServer(host) != nil {
+		return nil
+	}
+
+	server := config.ServerConfig{
+		ID:                 host,
+		Name:               host,
+		Icon:               "🔍",
+		PrometheusInstance: host,
+		Discovered:         true,
+	}
+	if net.ParseIP(host) != nil {
+		server.IP = host
+	}
+
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Job < targets[j].Job })
+	for _, t := range targets {
+		if exporter, ok := d.exporterJobs[t.Job]; ok {
+			if server.Exporter == "" {
+				server.Exporter = exporter
+			}
+			continue
+		}
+		if !hasJob(server.Services, t.Job) {
+			server.Services = append(server.Services, config.ServiceConfig{Name: t.Job, Job: t.Job, Discovered: true})
+		}
+	}
+	if server.Exporter == "" {
+		return nil // no host metrics to check the server with
+	}
+	return &server
+}
+
+// addServer adds a server to the discovery cloud of a data source, creating the cloud if needed
+// Servers of other data sources than default go to "<cloud> (<datasource>)"
+func (d *Discoverer) addServer(datasource string, server config.ServerConfig) {
+	infra := &d.config.Infrastructure
+	name := infra.Discovery.Cloud
+	if datasource != config.DefaultDatasource {
+		name = fmt.Sprintf("%s (%s)", name, datasource)
+	}
+
+	for i := range infra.Clouds {
+		if infra.Clouds[i].Name == name {
+			infra.Clouds[i].Servers = append(infra.Clouds[i].Servers, server)
+			return
+		}
+	}
+	cloud := config.CloudConfig{Name: name, Icon: "🔍", Servers: []config.ServerConfig{server}}
+	if datasource != config.DefaultDatasource {
+		cloud.Datasource = datasource
+	}
+	infra.Clouds = append(infra.Clouds, cloud)
+}
+
+// hasJob reports whether services contain one with the given job
+func hasJob(services []config.ServiceConfig, job string) bool {
+	for _, svc := range services {
+		if svc.Job == job {
+			return true
+		}
+	}
+	return false
+}
+
+// instanceHost returns the host of an instance label ("10.0.1.11:9100" -> "10.0.1.11")
+func instanceHost(instance string) string {
+	if host, _, err := net.SplitHostPort(instance); err == nil {
+		return host
+	}
+	return instance
+}
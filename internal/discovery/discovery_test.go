@@ -0,0 +1,206 @@
+package discovery
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/config"
+	"github.com/scinfra-pro/scinfra-bot/internal/health"
+)
+
+// newTestDiscoverer returns a discoverer with cached targets, so no data
+// source is queried
+func newTestDiscoverer(t *testing.T, cfg *config.Config, targets []Target) *Discoverer {
+	t.Helper()
+	checker, err := health.NewChecker(cfg, nil)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	d := New(cfg, checker)
+	d.targets = targets
+	d.fetched = time.Now()
+	return d
+}
+
+// testConfig has one server (web) scraped as 10.0.1.11 by the default data source
+func testConfig(services ...config.ServiceConfig) *config.Config {
+	return &config.Config{
+		Infrastructure: config.InfrastructureConfig{
+			Datasources: []config.DatasourceConfig{
+				{Name: config.DefaultDatasource, URL: "http://127.0.0.1:9090"},
+				{Name: "other", URL: "http://127.0.0.1:9091"},
+			},
+			Discovery: config.DiscoveryConfig{
+				Enabled:       true,
+				CreateServers: true,
+				Cloud:         "Discovered",
+				IgnoreJobs:    []string{"prometheus"},
+			},
+			Clouds: []config.CloudConfig{{
+				Name: "Production",
+				Servers: []config.ServerConfig{{
+					ID:                 "web",
+					Name:               "Web",
+					PrometheusInstance: "10.0.1.11",
+					Services:           services,
+				}},
+			}},
+		},
+	}
+}
+
+func TestInstanceHost(t *testing.T) {
+	tests := []struct {
+		instance string
+		want     string
+	}{
+		{"10.0.1.11:9100", "10.0.1.11"},
+		{"10.0.1.11", "10.0.1.11"},
+		{"web.example.com:443", "web.example.com"},
+		{"[2001:db8::1]:9100", "2001:db8::1"},
+		{"web", "web"},
+	}
+	for _, tt := range tests {
+		if got := instanceHost(tt.instance); got != tt.want {
+			t.Errorf("instanceHost(%q) = %q, want %q", tt.instance, got, tt.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	cfg := testConfig(config.ServiceConfig{Name: "Redis", Job: "redis"})
+	d := newTestDiscoverer(t, cfg, []Target{
+		{Datasource: "default", Job: "node", Instance: "10.0.1.11:9100"},        // exporter: not a service
+		{Datasource: "default", Job: "nginx", Instance: "10.0.1.11:9113"},       // attached
+		{Datasource: "default", Job: "redis", Instance: "10.0.1.11:9121"},       // already declared
+		{Datasource: "default", Job: "prometheus", Instance: "10.0.1.11:9090"},  // ignored
+		{Datasource: "other", Job: "haproxy", Instance: "10.0.1.11:8404"},       // other data source
+		{Datasource: "default", Job: "node", Instance: "10.0.1.50:9100"},        // new server
+		{Datasource: "default", Job: "mysql", Instance: "10.0.1.50:9104"},       // its service
+		{Datasource: "default", Job: "blackbox", Instance: "10.0.1.60:9115"},    // no exporter: skipped
+		{Datasource: "other", Job: "node", Instance: "backup.example.com:9100"}, // new server, other cloud
+	})
+
+	result, err := d.Apply()
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if want := (Result{Attached: 1, Created: 2}); result != want {
+		t.Errorf("Apply() = %+v, want %+v", result, want)
+	}
+
+	web := cfg.GetServer("web")
+	wantServices := []config.ServiceConfig{
+		{Name: "Redis", Job: "redis"},
+		{Name: "nginx", Job: "nginx", Discovered: true},
+	}
+	if !reflect.DeepEqual(web.Services, wantServices) {
+		t.Errorf("web services = %+v, want %+v", web.Services, wantServices)
+	}
+
+	tests := []struct {
+		serverID string
+		cloud    string
+		exporter string
+		ip       string
+		services []string
+	}{
+		{serverID: "10.0.1.50", cloud: "Discovered", exporter: "node_exporter", ip: "10.0.1.50", services: []string{"mysql"}},
+		{serverID: "backup.example.com", cloud: "Discovered (other)", exporter: "node_exporter"},
+	}
+	for _, tt := range tests {
+		server := cfg.GetServer(tt.serverID)
+		if server == nil {
+			t.Errorf("server %s not created", tt.serverID)
+			continue
+		}
+		var services []string
+		for _, svc := range server.Services {
+			services = append(services, svc.Job)
+		}
+		if cloud := cfg.GetServerCloud(tt.serverID); cloud != tt.cloud ||
+			server.Exporter != tt.exporter || server.IP != tt.ip ||
+			!server.Discovered || !reflect.DeepEqual(services, tt.services) {
+			t.Errorf("server %s = %+v in %q, want exporter %s, IP %q, services %v in %q",
+				tt.serverID, *server, cloud, tt.exporter, tt.ip, tt.services, tt.cloud)
+		}
+	}
+	if cfg.GetServer("10.0.1.60") != nil {
+		t.Errorf("server without exporter created")
+	}
+	if ds := cfg.GetServerDatasource("backup.example.com"); ds != "other" {
+		t.Errorf("backup.example.com datasource = %q, want other", ds)
+	}
+}
+
+func TestApplyWithoutCreateServers(t *testing.T) {
+	cfg := testConfig()
+	cfg.Infrastructure.Discovery.CreateServers = false
+	d := newTestDiscoverer(t, cfg, []Target{
+		{Datasource: "default", Job: "node", Instance: "10.0.1.50:9100"},
+	})
+
+	result, err := d.Apply()
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if result != (Result{}) || len(cfg.Infrastructure.Clouds) != 1 {
+		t.Errorf("Apply() = %+v with %d clouds, want no changes", result, len(cfg.Infrastructure.Clouds))
+	}
+}
+
+func TestDrift(t *testing.T) {
+	tests := []struct {
+		name     string
+		services []config.ServiceConfig
+		targets  []Target
+		want     []Drift
+	}{
+		{
+			name:     "in sync",
+			services: []config.ServiceConfig{{Name: "Nginx", Job: "nginx"}},
+			targets:  []Target{{Datasource: "default", Job: "nginx", Instance: "10.0.1.11:9113"}},
+		},
+		{
+			name: "missing and undeclared",
+			services: []config.ServiceConfig{
+				{Name: "Redis", Job: "redis"},
+				{Name: "Postgres", Job: "postgres"},
+				{Name: "Cron"}, // no job: not compared
+			},
+			targets: []Target{
+				{Datasource: "default", Job: "node", Instance: "10.0.1.11:9100"},
+				{Datasource: "default", Job: "redis", Instance: "10.0.1.11:9121"},
+				{Datasource: "default", Job: "nginx", Instance: "10.0.1.11:9113"},
+				{Datasource: "default", Job: "prometheus", Instance: "10.0.1.11:9090"},
+			},
+			want: []Drift{{ServerID: "web", Missing: []string{"postgres"}, Undeclared: []string{"nginx"}}},
+		},
+		{
+			name:     "targets of other data sources are not the server's",
+			services: []config.ServiceConfig{{Name: "Nginx", Job: "nginx"}},
+			targets:  []Target{{Datasource: "other", Job: "nginx", Instance: "10.0.1.11:9113"}},
+			want:     []Drift{{ServerID: "web", Missing: []string{"nginx"}}},
+		},
+		{
+			name:     "discovered services are not declared",
+			services: []config.ServiceConfig{{Name: "nginx", Job: "nginx", Discovered: true}},
+			targets:  []Target{{Datasource: "default", Job: "nginx", Instance: "10.0.1.11:9113"}},
+			want:     []Drift{{ServerID: "web", Undeclared: []string{"nginx"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestDiscoverer(t, testConfig(tt.services...), tt.targets)
+			got, err := d.Drift()
+			if err != nil {
+				t.Fatalf("Drift() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Drift() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
-	"net"
+	"log"
+	"math"
 	"net/http"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/scinfra-pro/scinfra-bot/internal/config"
@@ -37,17 +40,43 @@ type ServerStatus struct {
 	DiskUsedGB  float64
 	DiskTotalGB float64
 
+	// All mounted filesystems (for display, root filesystem included)
+	Filesystems []FilesystemStatus
+
+	// Filesystems predicted to fill up within DiskFillHorizon, soonest first (Prometheus only)
+	DiskFill []DiskFillForecast
+
+	// CPU core count (0 if unknown)
+	CPUCores int
+
+	// Network throughput in bytes per second (physical interfaces)
+	NetworkRxBytesPerSec float64
+	NetworkTxBytesPerSec float64
+
 	// Uptime
 	Uptime time.Duration
 
-	// External accessibility check
-	ExternalAccess  bool          // HTTPS/TCP probe succeeded
-	ExternalLatency time.Duration // Response time
-	ExternalError   string        // Error message if failed
+	// External accessibility check (summary of ExternalChecks)
+	ExternalAccess  bool          // All external checks succeeded
+	ExternalLatency time.Duration // Response time of the first check
+	ExternalError   string        // Error message of the first failed check
+	ExternalChecks  []ExternalCheckResult
 
 	// Services status
 	Services []ServiceStatus
 
+	// Probe error if the server could not be checked at all (e.g. "probe timeout")
+	ProbeError string
+
+	// Why the server is considered down (e.g. "switch-gate API: ssh: timeout")
+	DownReason string
+
+	// Failed metric queries while the server is up (e.g. "Prometheus query failed: cpu")
+	CheckErrors []string
+
+	// Effective thresholds (global → cloud → server)
+	Thresholds config.ThresholdsConfig
+
 	// SSH statistics (for remote VPS via switch-gate)
 	SSHLatency      time.Duration // Last SSH command latency
 	SSHSuccessCount int           // Successful SSH commands since restart
@@ -63,8 +92,28 @@ type ServiceStatus struct {
 	Port  int    // Port number
 	IsUp  bool   // Service is running
 	Error string // Error if failed
+
+	// Criticality: "critical" (server down), "warning" (server degraded), "info" (ignored)
+	Criticality string
+}
+
+// FilesystemStatus represents usage of a mounted filesystem
+type FilesystemStatus struct {
+	Mountpoint  string  // "/", "/data"
+	UsedPercent float64 // 0-100%
+	UsedGB      float64 // 0 if unknown (Prometheus servers report usage only)
+	TotalGB     float64
 }
 
+// DiskFillForecast is the predicted time until a filesystem is full
+type DiskFillForecast struct {
+	Mountpoint string        // "/", "/data", "C:"
+	FillsIn    time.Duration // at the usage trend of DiskTrendWindow
+}
+
+// DiskFillHorizon limits disk fill forecasts; trends further ahead are ignored
+const DiskFillHorizon = 30 * 24 * time.Hour
+
 // StatusLevel represents the health level
 type StatusLevel string
 
@@ -87,30 +136,84 @@ type EdgeSSHStats struct {
 type EdgeSSHStatsGetter func() EdgeSSHStats
 
 // Checker performs health checks on infrastructure
+// Checker is safe for concurrent use
 type Checker struct {
-	prometheus        *prometheus.Client
+	datasources       map[string]*prometheus.Client // key is data source name
+	exporters         map[string]*Exporter          // query templates by exporter type
 	config            *config.Config
 	httpClient        *http.Client
 	switchGateClients map[string]*switchgate.Client // key is upstream name (e.g., "primary")
 	edgeSSHStatsFunc  EdgeSSHStatsGetter            // for edge-gateway SSH stats
+	probes            *ProbeRegistry                // probe kinds by name
+	fleet             fleetCache                    // fleet-wide Prometheus results per data source
 
-	// Cache
+	// Probing
+	probeConcurrency int           // max servers probed in parallel
+	probeTimeout     time.Duration // per-server deadline
+
+	// Cache (guarded by mu)
+	mu        sync.RWMutex
 	cache     map[string]*ServerStatus // serverID -> status
 	cacheTime time.Time
 	cacheTTL  time.Duration
+	inflight  *refreshCall // refresh in progress, shared by concurrent callers
+}
+
+// refreshCall represents an in-flight refresh shared by concurrent callers
+type refreshCall struct {
+	done     chan struct{}
+	statuses []*ServerStatus
+	err      error
 }
 
 // DefaultCacheTTL is the default cache time-to-live
 const DefaultCacheTTL = 60 * time.Second
 
+// ProbeTimeoutError is the probe error for servers that did not respond in time
+const ProbeTimeoutError = "probe timeout"
+
 // NewChecker creates a new health checker
-func NewChecker(cfg *config.Config, sgClients map[string]*switchgate.Client) *Checker {
-	promClient := prometheus.NewClient(cfg.Infrastructure.PrometheusURL)
+// Returns an error if a data source cannot be set up (e.g. unreadable CA bundle)
+// or an exporter is invalid or unknown
+func NewChecker(cfg *config.Config, sgClients map[string]*switchgate.Client) (*Checker, error) {
+	datasources := make(map[string]*prometheus.Client)
+	for _, ds := range cfg.Infrastructure.Datasources {
+		client, err := prometheus.NewClientWithOptions(ds.URL, prometheus.Options{
+			Username:           ds.BasicAuth.Username,
+			Password:           ds.BasicAuth.Password,
+			BearerToken:        ds.BearerToken,
+			CAFile:             ds.CAFile,
+			CertFile:           ds.CertFile,
+			KeyFile:            ds.KeyFile,
+			InsecureSkipVerify: ds.InsecureSkipVerify,
+			Headers:            ds.Headers,
+			Timeout:            ds.Timeout,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("datasource %s: %w", ds.Name, err)
+		}
+		datasources[ds.Name] = client
+	}
 
-	return &Checker{
-		prometheus:        promClient,
+	exporters, err := buildExporters(cfg.Infrastructure.Exporters)
+	if err != nil {
+		return nil, err
+	}
+	for _, cloud := range cfg.Infrastructure.Clouds {
+		for _, server := range cloud.Servers {
+			if _, ok := exporters[server.ExporterType()]; !ok {
+				return nil, fmt.Errorf("server %s: unknown exporter %q", server.ID, server.Exporter)
+			}
+		}
+	}
+
+	c := &Checker{
+		datasources:       datasources,
+		exporters:         exporters,
 		config:            cfg,
 		switchGateClients: sgClients,
+		probeConcurrency:  cfg.Infrastructure.ProbeConcurrency,
+		probeTimeout:      cfg.Infrastructure.ProbeTimeout,
 		cache:             make(map[string]*ServerStatus),
 		cacheTTL:          DefaultCacheTTL,
 		httpClient: &http.Client{
@@ -121,10 +224,46 @@ func NewChecker(cfg *config.Config, sgClients map[string]*switchgate.Client) *Ch
 				},
 			},
 		},
+		probes: NewProbeRegistry(),
 	}
+	c.registerBuiltinProbes()
+	return c, nil
+}
+
+// datasourceFor returns the data source name of a server's cloud
+func (c *Checker) datasourceFor(server *config.ServerConfig) string {
+	name := c.config.GetServerDatasource(server.ID)
+	if _, ok := c.datasources[name]; !ok {
+		return config.DefaultDatasource
+	}
+	return name
+}
+
+// prometheusFor returns the Prometheus client of a server's cloud
+func (c *Checker) prometheusFor(server *config.ServerConfig) *prometheus.Client {
+	return c.datasources[c.datasourceFor(server)]
+}
+
+// exporterFor returns the query templates of a server's exporter type
+func (c *Checker) exporterFor(server *config.ServerConfig) *Exporter {
+	return c.exporters[server.ExporterType()]
+}
+
+// fleetFor returns the fleet metrics of a server's data source and exporter
+// Results are shared by all servers of the data source and exporter within fleetTTL
+func (c *Checker) fleetFor(ctx context.Context, server *config.ServerConfig) *FleetMetrics {
+	name := c.datasourceFor(server)
+	return c.fleet.get(ctx, name, c.datasources[name], c.exporterFor(server))
+}
+
+// RegisterProbe adds a probe kind that servers can declare in `probes`
+// Must be called before the checker is used
+func (c *Checker) RegisterProbe(kind string, probe Probe) {
+	c.probes.Register(kind, probe)
 }
 
 // SetEdgeSSHStatsFunc sets the function to get edge-gateway SSH stats
+// Must be called before the checker is used
 func (c *Checker) SetEdgeSSHStatsFunc(fn EdgeSSHStatsGetter) {
 	c.edgeSSHStatsFunc = fn
 }
@@ -132,8 +271,8 @@ func (c *Checker) SetEdgeSSHStatsFunc(fn EdgeSSHStatsGetter) {
 // CheckAll checks all configured servers (uses cache if valid)
 func (c *Checker) CheckAll() ([]*ServerStatus, error) {
 	// Return from cache if still valid
-	if c.isCacheValid() {
-		return c.getCachedStatuses(), nil
+	if statuses, ok := c.getCachedStatuses(); ok {
+		return statuses, nil
 	}
 
 	return c.refreshAll()
@@ -145,29 +284,93 @@ func (c *Checker) CheckAllForce() ([]*ServerStatus, error) {
 }
 
 // refreshAll fetches fresh data and updates cache
+// Simultaneous callers share a single in-flight refresh
 func (c *Checker) refreshAll() ([]*ServerStatus, error) {
-	var statuses []*ServerStatus
+	c.mu.Lock()
+	if call := c.inflight; call != nil {
+		c.mu.Unlock()
+		<-call.done
+		return call.statuses, call.err
+	}
+	call := &refreshCall{done: make(chan struct{})}
+	c.inflight = call
+	c.mu.Unlock()
 
+	call.statuses, call.err = c.probeAll()
+
+	c.mu.Lock()
+	for _, status := range call.statuses {
+		c.cache[status.ID] = status
+	}
+	c.cacheTime = time.Now()
+	c.inflight = nil
+	c.mu.Unlock()
+
+	close(call.done)
+	return call.statuses, call.err
+}
+
+// probeAll probes all servers in parallel with a bounded worker pool
+// Results are returned in config order
+func (c *Checker) probeAll() ([]*ServerStatus, error) {
+	type probeJob struct {
+		server    config.ServerConfig
+		cloudName string
+		cloudIcon string
+	}
+
+	var jobs []probeJob
 	for _, cloud := range c.config.Infrastructure.Clouds {
 		for _, server := range cloud.Servers {
-			status := c.checkServer(&server, cloud.Name, cloud.Icon)
-			statuses = append(statuses, status)
-			// Update cache
-			c.cache[server.ID] = status
+			jobs = append(jobs, probeJob{server: server, cloudName: cloud.Name, cloudIcon: cloud.Icon})
 		}
 	}
 
-	c.cacheTime = time.Now()
+	statuses := make([]*ServerStatus, len(jobs))
+	sem := make(chan struct{}, c.probeConcurrency)
+	var wg sync.WaitGroup
+
+	for i, job := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, job probeJob) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			statuses[i] = c.probeServer(&job.server, job.cloudName, job.cloudIcon)
+		}(i, job)
+	}
+	wg.Wait()
+
 	return statuses, nil
 }
 
+// probeServer runs all checks for a server within the per-server deadline
+// A server that does not finish in time is marked down with ProbeTimeoutError
+func (c *Checker) probeServer(server *config.ServerConfig, cloudName, cloudIcon string) *ServerStatus {
+	ctx, cancel := context.WithTimeout(context.Background(), c.probeTimeout)
+	defer cancel()
+
+	result := make(chan *ServerStatus, 1)
+	go func() {
+		result <- c.checkServer(ctx, server, cloudName, cloudIcon)
+	}()
+
+	select {
+	case status := <-result:
+		return status
+	case <-ctx.Done():
+		log.Printf("Health check: %s did not respond within %s", server.ID, c.probeTimeout)
+		status := c.newServerStatus(server, cloudName, cloudIcon)
+		status.ProbeError = ProbeTimeoutError
+		return status
+	}
+}
+
 // CheckServer checks a single server by ID (uses cache if valid)
 func (c *Checker) CheckServer(serverID string) (*ServerStatus, error) {
 	// Return from cache if valid
-	if c.isCacheValid() {
-		if status, ok := c.cache[serverID]; ok {
-			return status, nil
-		}
+	if status, ok := c.getCachedStatus(serverID); ok {
+		return status, nil
 	}
 
 	return c.checkServerForce(serverID)
@@ -194,119 +397,82 @@ func (c *Checker) checkServerForce(serverID string) (*ServerStatus, error) {
 		}
 	}
 
-	return c.checkServer(server, cloudName, cloudIcon), nil
+	return c.probeServer(server, cloudName, cloudIcon), nil
 }
 
-// checkServer performs all health checks for a server
-func (c *Checker) checkServer(server *config.ServerConfig, cloudName, cloudIcon string) *ServerStatus {
-	status := &ServerStatus{
-		ID:        server.ID,
-		Name:      server.Name,
-		Icon:      server.Icon,
-		CloudName: cloudName,
-		CloudIcon: cloudIcon,
-		IP:        server.IP,
-	}
-
-	// Check if this is a switch-gate server (remote VPS)
-	upstreamKey := c.config.GetUpstreamByIP(server.IP)
-	if upstreamKey != "" && c.config.IsSwitchGateServer(server.IP) {
-		// Use switch-gate client for remote VPS
-		c.checkSwitchGateServer(status, server, upstreamKey)
-	} else {
-		// Use Prometheus for local/cloud servers
-		c.checkPrometheusServer(status, server)
+// newServerStatus creates an empty status for a server
+func (c *Checker) newServerStatus(server *config.ServerConfig, cloudName, cloudIcon string) *ServerStatus {
+	return &ServerStatus{
+		ID:         server.ID,
+		Name:       server.Name,
+		Icon:       server.Icon,
+		CloudName:  cloudName,
+		CloudIcon:  cloudIcon,
+		IP:         server.IP,
+		Thresholds: c.config.GetServerThresholds(server.ID),
 	}
+}
 
-	// Check external accessibility
-	if server.ExternalCheck != "" {
-		accessible, latency, err := c.checkExternal(server.ExternalCheck)
-		status.ExternalAccess = accessible
-		status.ExternalLatency = latency
-		if err != nil {
-			status.ExternalError = err.Error()
+// applyServiceCriticality copies configured criticality to service statuses
+func applyServiceCriticality(status *ServerStatus, server *config.ServerConfig) {
+	for i := range status.Services {
+		if status.Services[i].Criticality != "" {
+			continue // set by the probe
+		}
+		status.Services[i].Criticality = config.CriticalityWarning
+		for _, svc := range server.Services {
+			if svc.Name == status.Services[i].Name && svc.Criticality != "" {
+				status.Services[i].Criticality = svc.Criticality
+				break
+			}
 		}
-	} else {
-		// No external check configured - mark as N/A (accessible if up)
-		status.ExternalAccess = status.IsUp
 	}
-
-	return status
 }
 
-// checkPrometheusServer checks a server using Prometheus metrics
-func (c *Checker) checkPrometheusServer(status *ServerStatus, server *config.ServerConfig) {
-	// Use PrometheusInstance for queries (matches instance label in Prometheus config)
-	promInstance := server.PrometheusInstance
-	if promInstance == "" {
-		promInstance = server.Name // Fallback to name if not set
+// serverProbes returns the probes declared for a server, or the default:
+// switch-gate for switch-gate upstreams, prometheus otherwise
+func (c *Checker) serverProbes(server *config.ServerConfig) []config.ProbeConfig {
+	if len(server.Probes) > 0 {
+		return server.Probes
 	}
-
-	// Check if server is up via Prometheus
-	isUp, err := c.prometheus.IsUp(promInstance)
-	if err != nil {
-		status.IsUp = false
-	} else {
-		status.IsUp = isUp
+	if c.config.GetUpstreamByIP(server.IP) != "" && c.config.IsSwitchGateServer(server.IP) {
+		return []config.ProbeConfig{{Kind: config.ProbeSwitchGate}}
 	}
+	return []config.ProbeConfig{{Kind: config.ProbePrometheus}}
+}
 
-	// Get metrics only if server is up
-	if status.IsUp {
-		// CPU
-		if cpu, err := c.prometheus.GetCPU(promInstance); err == nil {
-			status.CPU = cpu
-		}
-
-		// Memory
-		if mem, err := c.prometheus.GetMemory(promInstance); err == nil {
-			status.Memory = mem
-		}
-		if used, total, err := c.prometheus.GetMemoryBytes(promInstance); err == nil {
-			status.MemoryUsedGB = used / (1024 * 1024 * 1024)
-			status.MemoryTotalGB = total / (1024 * 1024 * 1024)
-		}
-
-		// Disk
-		if disk, err := c.prometheus.GetDisk(promInstance); err == nil {
-			status.Disk = disk
-		}
-		if used, total, err := c.prometheus.GetDiskBytes(promInstance); err == nil {
-			status.DiskUsedGB = used / (1024 * 1024 * 1024)
-			status.DiskTotalGB = total / (1024 * 1024 * 1024)
-		}
-
-		// Uptime
-		if uptime, err := c.prometheus.GetUptime(promInstance); err == nil {
-			status.Uptime = uptime
+// hasProbe reports whether probes contain the given kind
+func hasProbe(probes []config.ProbeConfig, kind string) bool {
+	for _, p := range probes {
+		if p.Kind == kind {
+			return true
 		}
 	}
+	return false
+}
 
-	// Check services via Prometheus
-	for _, svc := range server.Services {
-		svcStatus := ServiceStatus{
-			Name: svc.Name,
-			Job:  svc.Job,
-			Port: svc.Port,
-		}
+// checkServer performs all health checks for a server
+func (c *Checker) checkServer(ctx context.Context, server *config.ServerConfig, cloudName, cloudIcon string) *ServerStatus {
+	status := c.newServerStatus(server, cloudName, cloudIcon)
 
-		if svc.Job != "" {
-			// Check via Prometheus job
-			isUp, err := c.prometheus.IsServiceUp(svc.Job, promInstance)
-			svcStatus.IsUp = isUp
-			if err != nil {
-				svcStatus.Error = err.Error()
-			}
-		} else {
-			// If no job specified, assume running if server is up
-			svcStatus.IsUp = status.IsUp
+	// Run probes; the first unreachable error marks the server down
+	status.IsUp = true
+	probes := c.serverProbes(server)
+	for _, spec := range probes {
+		probe, ok := c.probes.Get(spec.Kind)
+		if !ok {
+			status.CheckErrors = append(status.CheckErrors, fmt.Sprintf("unknown probe kind %q", spec.Kind))
+			continue
+		}
+		if err := probe.Run(ctx, ProbeTarget{Server: server, Spec: spec}, status); err != nil && status.IsUp {
+			status.IsUp = false
+			status.DownReason = err.Error()
 		}
-
-		status.Services = append(status.Services, svcStatus)
 	}
 
-	// Check if this is edge-gateway and add SSH statistics
-	isEdge := c.isEdgeGateway(server.ID, server.Name, server.IP)
-	if c.edgeSSHStatsFunc != nil && isEdge {
+	// Edge-gateway SSH statistics (switch-gate servers report their own)
+	if c.edgeSSHStatsFunc != nil && !hasProbe(probes, config.ProbeSwitchGate) &&
+		c.isEdgeGateway(server.ID, server.Name, server.IP) {
 		sshStats := c.edgeSSHStatsFunc()
 		status.SSHLatency = sshStats.LastLatency
 		status.SSHSuccessCount = sshStats.SuccessCount
@@ -314,6 +480,27 @@ func (c *Checker) checkPrometheusServer(status *ServerStatus, server *config.Ser
 		status.SSHLastError = sshStats.LastError
 		status.SSHLastErrorAt = sshStats.LastErrorAt
 	}
+
+	applyServiceCriticality(status, server)
+
+	// Check external accessibility
+	if checks := server.ExternalCheckURLs(); len(checks) > 0 {
+		status.ExternalAccess = true
+		for _, target := range checks {
+			result := c.checkExternal(ctx, target)
+			status.ExternalChecks = append(status.ExternalChecks, result)
+			if !result.OK && status.ExternalAccess {
+				status.ExternalAccess = false
+				status.ExternalError = result.Error
+			}
+		}
+		status.ExternalLatency = status.ExternalChecks[0].Latency
+	} else {
+		// No external check configured - mark as N/A (accessible if up)
+		status.ExternalAccess = status.IsUp
+	}
+
+	return status
 }
 
 // isEdgeGateway checks if the server is the edge-gateway
@@ -334,182 +521,163 @@ func (c *Checker) isEdgeGateway(serverID, serverName, ip string) bool {
 	return ip == edgeHost
 }
 
-// checkSwitchGateServer checks a remote VPS using switch-gate API via SSH
-func (c *Checker) checkSwitchGateServer(status *ServerStatus, server *config.ServerConfig, upstreamKey string) {
-	sgClient, ok := c.switchGateClients[upstreamKey]
-	if !ok {
-		// No switch-gate client available
-		status.IsUp = false
-		return
-	}
+// GetStatusLevel returns the status level for a server
+func (s *ServerStatus) GetStatusLevel() StatusLevel {
+	level, _ := s.Evaluate()
+	return level
+}
 
-	// Get status from switch-gate API (this updates SSH statistics)
-	sgStatus, err := sgClient.GetStatus()
+// GetReasons returns why the server is not up (empty if healthy)
+func (s *ServerStatus) GetReasons() []string {
+	_, reasons := s.Evaluate()
+	return reasons
+}
 
-	// Get SSH statistics AFTER the call (so it includes this request)
-	sshStats := sgClient.GetSSHStats()
-	status.SSHLatency = sshStats.LastLatency
-	status.SSHSuccessCount = sshStats.SuccessCount
-	status.SSHErrorCount = sshStats.ErrorCount
-	status.SSHLastError = sshStats.LastError
-	status.SSHLastErrorAt = sshStats.LastErrorAt
+// Evaluate returns the status level and the reasons that led to it,
+// e.g. "disk 91% > 85%" or "service gost down: connection refused"
+func (s *ServerStatus) Evaluate() (StatusLevel, []string) {
+	if !s.IsUp {
+		switch {
+		case s.ProbeError != "":
+			return StatusDown, []string{s.ProbeError}
+		case s.DownReason != "":
+			return StatusDown, []string{s.DownReason}
+		default:
+			return StatusDown, []string{"server not responding"}
+		}
+	}
 
-	if err != nil {
-		status.IsUp = false
-		// Add error as service status
-		status.Services = append(status.Services, ServiceStatus{
-			Name:  "switch-gate",
-			Port:  9090,
-			IsUp:  false,
-			Error: err.Error(),
-		})
-		return
+	level := StatusUp
+	var reasons []string
+	raise := func(l StatusLevel, reason string) {
+		if l == StatusUp {
+			return
+		}
+		reasons = append(reasons, reason)
+		if l == StatusDown || (l == StatusDegraded && level == StatusUp) {
+			level = l
+		}
 	}
 
-	// Server is up if we got a response
-	status.IsUp = true
+	// Resource thresholds
+	th := s.Thresholds
+	raise(percentReason("CPU", s.CPU, th.CPU))
+	raise(percentReason("memory", s.Memory, th.Memory))
+	raise(percentReason("disk", s.Disk, th.Disk))
 
-	// Parse uptime from switch-gate status
-	if sgStatus.Uptime != "" {
-		if uptime, err := time.ParseDuration(sgStatus.Uptime); err == nil {
-			status.Uptime = uptime
-		}
+	// Recent reboot (uptime below threshold)
+	if s.Uptime > 0 {
+		raise(durationBelowLevel(s.Uptime, th.UptimeReset), fmt.Sprintf("rebooted %s ago", FormatDuration(s.Uptime)))
 	}
 
-	// Get system metrics from node_exporter
-	if nodeMetrics, err := sgClient.GetNodeMetrics(); err == nil {
-		// Memory
-		status.Memory = nodeMetrics.MemoryUsedPercent
-		status.MemoryUsedGB = nodeMetrics.MemoryUsedBytes / (1024 * 1024 * 1024)
-		status.MemoryTotalGB = nodeMetrics.MemoryTotalBytes / (1024 * 1024 * 1024)
-
-		// Disk
-		status.Disk = nodeMetrics.DiskUsedPercent
-		status.DiskUsedGB = nodeMetrics.DiskUsedBytes / (1024 * 1024 * 1024)
-		status.DiskTotalGB = nodeMetrics.DiskTotalBytes / (1024 * 1024 * 1024)
-
-		// CPU - use load1 as percentage approximation (rough, but useful)
-		// For single-core VPS, load1 of 1.0 = 100% CPU
-		// We cap at 100% for display purposes
-		status.CPU = nodeMetrics.Load1 * 100
-		if status.CPU > 100 {
-			status.CPU = 100
-		}
+	// Filesystems filling up at the current trend
+	for _, f := range s.DiskFill {
+		raise(durationBelowLevel(f.FillsIn, th.DiskFill), fmt.Sprintf("disk %s fills in %s", f.Mountpoint, FormatETA(f.FillsIn)))
 	}
 
-	// Add services based on config and switch-gate response
-	for _, svc := range server.Services {
-		svcStatus := ServiceStatus{
-			Name: svc.Name,
-			Port: svc.Port,
+	// Down services, by criticality
+	for _, svc := range s.Services {
+		if svc.IsUp {
+			continue
 		}
-
-		switch svc.Name {
-		case "switch-gate":
-			// switch-gate is up if we got status
-			svcStatus.IsUp = true
-		case "gost":
-			// gost is up if switch-gate is running (they're related)
-			svcStatus.IsUp = true
-		case "node_exporter":
-			// node_exporter is up if we got metrics
-			svcStatus.IsUp = status.Memory > 0
+		reason := fmt.Sprintf("service %s down", svc.Name)
+		if svc.Error != "" {
+			reason += ": " + svc.Error
+		}
+		switch svc.Criticality {
+		case config.CriticalityCritical:
+			raise(StatusDown, reason)
+		case config.CriticalityInfo:
+			// ignored
 		default:
-			// Other services - assume up if server responds
-			svcStatus.IsUp = true
+			raise(StatusDegraded, reason)
 		}
-
-		status.Services = append(status.Services, svcStatus)
 	}
-}
-
-// checkExternal performs an external accessibility check
-func (c *Checker) checkExternal(checkURL string) (bool, time.Duration, error) {
-	start := time.Now()
 
-	// Parse check type
-	if strings.HasPrefix(checkURL, "tcp://") {
-		// TCP check
-		addr := strings.TrimPrefix(checkURL, "tcp://")
-		return c.checkTCP(addr, start)
-	}
-
-	// Default: HTTPS/HTTP check
-	return c.checkHTTP(checkURL, start)
-}
-
-// checkHTTP performs an HTTP/HTTPS check
-func (c *Checker) checkHTTP(url string, start time.Time) (bool, time.Duration, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return false, 0, fmt.Errorf("invalid URL: %w", err)
-	}
+	// External checks (the check is named only if a server has several)
+	multiple := len(s.ExternalChecks) > 1
+	for _, check := range s.ExternalChecks {
+		suffix := ""
+		if multiple {
+			suffix = " (" + check.Label() + ")"
+		}
 
-	resp, err := c.httpClient.Do(req)
-	latency := time.Since(start)
+		if !check.OK {
+			raise(StatusDegraded, "external check "+check.Error+suffix)
+		}
 
-	if err != nil {
-		return false, latency, fmt.Errorf("connection failed: %w", err)
+		// TLS certificate
+		if cert := check.Cert; cert != nil {
+			days := cert.DaysLeft()
+			reason := fmt.Sprintf("cert expires in %d days", days)
+			if days < 0 {
+				reason = fmt.Sprintf("cert expired %d days ago", -days)
+			}
+			raise(belowLevel(float64(days), th.TLSExpiry), reason+suffix)
+		}
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	// Consider 2xx and 3xx as success
-	if resp.StatusCode >= 200 && resp.StatusCode < 400 {
-		return true, latency, nil
+	// Metrics that could not be collected
+	for _, e := range s.CheckErrors {
+		raise(StatusDegraded, e)
 	}
 
-	return false, latency, fmt.Errorf("HTTP %d", resp.StatusCode)
+	return level, reasons
 }
 
-// checkTCP performs a TCP connection check
-func (c *Checker) checkTCP(addr string, start time.Time) (bool, time.Duration, error) {
-	conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
-	latency := time.Since(start)
-
-	if err != nil {
-		return false, latency, fmt.Errorf("connection failed: %w", err)
+// percentReason returns the level and reason for a percentage metric
+func percentReason(name string, value float64, th config.Threshold) (StatusLevel, string) {
+	level := percentLevel(value, th)
+	limit := th.Warn
+	if level == StatusDown {
+		limit = th.Critical
 	}
-	defer func() { _ = conn.Close() }()
-
-	return true, latency, nil
+	return level, fmt.Sprintf("%s %.0f%% > %.0f%%", name, value, limit)
 }
 
-// GetStatusLevel returns the status level for a server
-func (s *ServerStatus) GetStatusLevel() StatusLevel {
-	if !s.IsUp {
+// percentLevel returns the level for a percentage against a threshold
+// Non-positive threshold values are disabled
+func percentLevel(value float64, th config.Threshold) StatusLevel {
+	switch {
+	case th.Critical > 0 && value > th.Critical:
 		return StatusDown
+	case th.Warn > 0 && value > th.Warn:
+		return StatusDegraded
+	default:
+		return StatusUp
 	}
+}
 
-	// Check for degraded conditions
-	if s.CPU > 80 || s.Memory > 85 || s.Disk > 85 {
+// belowLevel returns the level for a value that is bad when low (e.g. days left)
+// Non-positive threshold values are disabled
+func belowLevel(value float64, th config.Threshold) StatusLevel {
+	switch {
+	case th.Critical > 0 && value < th.Critical:
+		return StatusDown
+	case th.Warn > 0 && value < th.Warn:
 		return StatusDegraded
+	default:
+		return StatusUp
 	}
+}
 
-	// Check if any service is down
-	for _, svc := range s.Services {
-		if !svc.IsUp {
-			return StatusDegraded
-		}
+// durationBelowLevel returns the level for a duration that is bad when short
+// (e.g. uptime, time until a disk is full)
+// Non-positive threshold values are disabled
+func durationBelowLevel(d time.Duration, th config.DurationThreshold) StatusLevel {
+	switch {
+	case th.Critical > 0 && d < th.Critical:
+		return StatusDown
+	case th.Warn > 0 && d < th.Warn:
+		return StatusDegraded
+	default:
+		return StatusUp
 	}
-
-	return StatusUp
 }
 
 // GetStatusIcon returns the status icon for a server
 func (s *ServerStatus) GetStatusIcon() string {
-	switch s.GetStatusLevel() {
-	case StatusUp:
-		return "🟢"
-	case StatusDegraded:
-		return "🟡"
-	case StatusDown:
-		return "🛑"
-	default:
-		return "⚪"
-	}
+	return levelIcon(s.GetStatusLevel())
 }
 
 // GetExternalIcon returns the external accessibility icon
@@ -525,10 +693,14 @@ func (s *ServerStatus) FormatUptime() string {
 	if s.Uptime == 0 {
 		return "unknown"
 	}
+	return FormatDuration(s.Uptime)
+}
 
-	days := int(s.Uptime.Hours() / 24)
-	hours := int(s.Uptime.Hours()) % 24
-	minutes := int(s.Uptime.Minutes()) % 60
+// FormatDuration returns a human-readable duration ("2d 3h 4m", "3h 4m", "4m")
+func FormatDuration(d time.Duration) string {
+	days := int(d.Hours() / 24)
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
 
 	if days > 0 {
 		return fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
@@ -539,6 +711,18 @@ func (s *ServerStatus) FormatUptime() string {
 	return fmt.Sprintf("%dm", minutes)
 }
 
+// FormatETA returns a rounded duration for predictions ("~3d", "~5h", "~40m")
+func FormatETA(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("~%dd", int(math.Round(d.Hours()/24)))
+	case d >= time.Hour:
+		return fmt.Sprintf("~%dh", int(math.Round(d.Hours())))
+	default:
+		return fmt.Sprintf("~%dm", int(math.Max(1, math.Round(d.Minutes()))))
+	}
+}
+
 // FormatProgressBar returns a text progress bar
 func FormatProgressBar(percent float64, width int) string {
 	if percent < 0 {
@@ -554,21 +738,132 @@ func FormatProgressBar(percent float64, width int) string {
 	return strings.Repeat("▓", filled) + strings.Repeat("░", empty)
 }
 
-// Ping checks if Prometheus is reachable
+// Ping checks if the Prometheus data sources used by clouds are reachable
+// Returns an error only if none of them is; unreachable ones are logged
 func (c *Checker) Ping() error {
-	return c.prometheus.Ping()
+	var lastErr error
+	reachable := 0
+	for _, name := range c.UsedDatasources() {
+		client := c.datasources[name]
+		if err := client.Ping(); err != nil {
+			log.Printf("Health check: datasource %s: %v", name, err)
+			lastErr = err
+			continue
+		}
+		reachable++
+	}
+	if reachable == 0 {
+		return lastErr
+	}
+	return nil
+}
+
+// UsedDatasources returns the sorted names of the data sources used by clouds
+// (the default data source if there are no clouds)
+func (c *Checker) UsedDatasources() []string {
+	used := map[string]bool{}
+	for _, cloud := range c.config.Infrastructure.Clouds {
+		name := cloud.Datasource
+		if name == "" {
+			name = config.DefaultDatasource
+		}
+		used[name] = true
+	}
+	if len(used) == 0 {
+		used[config.DefaultDatasource] = true
+	}
+
+	names := make([]string, 0, len(used))
+	for name := range used {
+		if _, ok := c.datasources[name]; ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExporterJobs returns the exporter type of each exporter job label
+// (e.g. "node" -> node_exporter)
+func (c *Checker) ExporterJobs() map[string]string {
+	jobs := make(map[string]string, len(c.exporters))
+	for name, e := range c.exporters {
+		jobs[e.Job] = name
+	}
+	return jobs
+}
+
+// Datasource returns the Prometheus client of a data source ("" is the default)
+func (c *Checker) Datasource(name string) (*prometheus.Client, error) {
+	if name == "" {
+		name = config.DefaultDatasource
+	}
+	client, ok := c.datasources[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown datasource: %s", name)
+	}
+	return client, nil
+}
+
+// PrometheusTarget describes where the metrics of a server come from
+type PrometheusTarget struct {
+	Client   *prometheus.Client
+	Instance string    // instance label
+	Exporter *Exporter // query templates
 }
 
-// isCacheValid returns true if cache is still valid (within TTL)
-func (c *Checker) isCacheValid() bool {
+// PrometheusTarget returns the Prometheus client, instance label and exporter of a server
+// Returns an error if the server is not checked with the prometheus probe
+func (c *Checker) PrometheusTarget(serverID string) (*PrometheusTarget, error) {
+	server := c.config.GetServer(serverID)
+	if server == nil {
+		return nil, fmt.Errorf("server not found: %s", serverID)
+	}
+	if !hasProbe(c.serverProbes(server), config.ProbePrometheus) {
+		return nil, fmt.Errorf("%s has no Prometheus metrics", server.Name)
+	}
+	return &PrometheusTarget{
+		Client:   c.prometheusFor(server),
+		Instance: prometheusInstance(server),
+		Exporter: c.exporterFor(server),
+	}, nil
+}
+
+// isCacheValidLocked returns true if cache is still valid (within TTL)
+// Caller must hold c.mu
+func (c *Checker) isCacheValidLocked() bool {
 	if len(c.cache) == 0 {
 		return false
 	}
 	return time.Since(c.cacheTime) < c.cacheTTL
 }
 
-// getCachedStatuses returns all cached statuses in order
-func (c *Checker) getCachedStatuses() []*ServerStatus {
+// getCachedStatuses returns all cached statuses in order if cache is valid
+func (c *Checker) getCachedStatuses() ([]*ServerStatus, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.isCacheValidLocked() {
+		return nil, false
+	}
+
+	var statuses []*ServerStatus
+	for _, cloud := range c.config.Infrastructure.Clouds {
+		for _, server := range cloud.Servers {
+			if status, ok := c.cache[server.ID]; ok {
+				statuses = append(statuses, status)
+			}
+		}
+	}
+	return statuses, true
+}
+
+// LastStatuses returns the most recent statuses in config order, even if the
+// cache expired, and when they were checked (nothing is probed)
+func (c *Checker) LastStatuses() ([]*ServerStatus, time.Time) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	var statuses []*ServerStatus
 	for _, cloud := range c.config.Infrastructure.Clouds {
 		for _, server := range cloud.Servers {
@@ -577,11 +872,26 @@ func (c *Checker) getCachedStatuses() []*ServerStatus {
 			}
 		}
 	}
-	return statuses
+	return statuses, c.cacheTime
+}
+
+// getCachedStatus returns cached status for a server if cache is valid
+func (c *Checker) getCachedStatus(serverID string) (*ServerStatus, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.isCacheValidLocked() {
+		return nil, false
+	}
+	status, ok := c.cache[serverID]
+	return status, ok
 }
 
 // InvalidateCache clears the cache
 func (c *Checker) InvalidateCache() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	c.cache = make(map[string]*ServerStatus)
 	c.cacheTime = time.Time{}
 }
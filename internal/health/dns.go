@@ -0,0 +1,290 @@
+package health
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// DNS checks send their query straight to the configured server (no
+// /etc/hosts, no search domains, no system resolver) with this minimal client
+
+// dnsTypes are the supported record types and their codes
+var dnsTypes = map[string]uint16{
+	"A":     1,
+	"NS":    2,
+	"CNAME": 5,
+	"MX":    15,
+	"TXT":   16,
+	"AAAA":  28,
+}
+
+// dnsRcodes names DNS response codes
+var dnsRcodes = map[int]string{
+	1: "FORMERR",
+	2: "SERVFAIL",
+	3: "NXDOMAIN",
+	4: "NOTIMP",
+	5: "REFUSED",
+}
+
+const (
+	dnsHeaderLen  = 12
+	dnsClassIN    = 1
+	dnsFlagQR     = 1 << 15
+	dnsFlagTC     = 1 << 9
+	dnsFlagRD     = 1 << 8
+	dnsMaxUDPSize = 4096
+	dnsMaxPointer = 16 // compression pointers followed per name
+)
+
+var errDNSMessage = errors.New("malformed DNS response")
+
+// queryDNS asks server (host:port) for the records of a type and returns the
+// answers of that type (trailing dots trimmed)
+// The query goes over UDP and is retried over TCP if the reply is truncated
+func queryDNS(ctx context.Context, server, recordType, name string) ([]string, error) {
+	qtype, ok := dnsTypes[recordType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported record type %q", recordType)
+	}
+
+	query, id, err := buildDNSQuery(name, qtype)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := exchangeDNS(ctx, "udp", server, query)
+	if err == nil && len(reply) >= dnsHeaderLen && binary.BigEndian.Uint16(reply[2:])&dnsFlagTC != 0 {
+		reply, err = exchangeDNS(ctx, "tcp", server, query)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query %s: %w", server, err)
+	}
+
+	return parseDNSReply(reply, id, qtype)
+}
+
+// buildDNSQuery returns a recursive query for one question and its ID
+func buildDNSQuery(name string, qtype uint16) ([]byte, uint16, error) {
+	var idBuf [2]byte
+	if _, err := rand.Read(idBuf[:]); err != nil {
+		return nil, 0, fmt.Errorf("read random: %w", err)
+	}
+	id := binary.BigEndian.Uint16(idBuf[:])
+
+	msg := make([]byte, dnsHeaderLen, dnsHeaderLen+len(name)+6)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	binary.BigEndian.PutUint16(msg[2:], dnsFlagRD)
+	binary.BigEndian.PutUint16(msg[4:], 1) // QDCOUNT
+
+	// Names are absolute: no search domains are appended
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "" || len(label) > 63 {
+			return nil, 0, fmt.Errorf("invalid name %q", name)
+		}
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0)
+	msg = binary.BigEndian.AppendUint16(msg, qtype)
+	msg = binary.BigEndian.AppendUint16(msg, dnsClassIN)
+	if len(msg) > dnsHeaderLen+255+4 {
+		return nil, 0, fmt.Errorf("invalid name %q: too long", name)
+	}
+	return msg, id, nil
+}
+
+// exchangeDNS sends a query over udp or tcp and returns the reply
+func exchangeDNS(ctx context.Context, network, server string, query []byte) ([]byte, error) {
+	dialer := &net.Dialer{Timeout: externalTimeout}
+	conn, err := dialer.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	deadline := time.Now().Add(externalTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+
+	if network == "udp" {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, dnsMaxUDPSize)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+
+	// TCP messages are prefixed with their length
+	framed := binary.BigEndian.AppendUint16(nil, uint16(len(query)))
+	if _, err := conn.Write(append(framed, query...)); err != nil {
+		return nil, err
+	}
+	var size [2]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return nil, err
+	}
+	reply := make([]byte, binary.BigEndian.Uint16(size[:]))
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// parseDNSReply checks a reply to query id and returns its answers of qtype
+// (CNAME records leading to them are skipped)
+func parseDNSReply(msg []byte, id, qtype uint16) ([]string, error) {
+	if len(msg) < dnsHeaderLen {
+		return nil, errDNSMessage
+	}
+	flags := binary.BigEndian.Uint16(msg[2:])
+	switch {
+	case binary.BigEndian.Uint16(msg[0:]) != id:
+		return nil, fmt.Errorf("%w: ID mismatch", errDNSMessage)
+	case flags&dnsFlagQR == 0:
+		return nil, fmt.Errorf("%w: not a response", errDNSMessage)
+	}
+	if rcode := int(flags & 0xF); rcode != 0 {
+		name, ok := dnsRcodes[rcode]
+		if !ok {
+			name = fmt.Sprintf("rcode %d", rcode)
+		}
+		return nil, fmt.Errorf("server answered %s", name)
+	}
+
+	qdcount := int(binary.BigEndian.Uint16(msg[4:]))
+	ancount := int(binary.BigEndian.Uint16(msg[6:]))
+
+	off := dnsHeaderLen
+	for i := 0; i < qdcount; i++ {
+		_, next, err := readDNSName(msg, off)
+		if err != nil {
+			return nil, err
+		}
+		off = next + 4 // QTYPE, QCLASS
+	}
+
+	var answers []string
+	for i := 0; i < ancount; i++ {
+		_, next, err := readDNSName(msg, off)
+		if err != nil {
+			return nil, err
+		}
+		if next+10 > len(msg) {
+			return nil, errDNSMessage
+		}
+		rtype := binary.BigEndian.Uint16(msg[next:])
+		rdlen := int(binary.BigEndian.Uint16(msg[next+8:]))
+		start := next + 10
+		end := start + rdlen
+		if end > len(msg) {
+			return nil, errDNSMessage
+		}
+		off = end
+
+		if rtype != qtype {
+			continue
+		}
+		answer, err := decodeDNSData(msg, rtype, start, end)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, strings.TrimSuffix(answer, "."))
+	}
+	return answers, nil
+}
+
+// decodeDNSData decodes the data of a record at msg[start:end]
+func decodeDNSData(msg []byte, rtype uint16, start, end int) (string, error) {
+	data := msg[start:end]
+	switch rtype {
+	case dnsTypes["A"]:
+		if len(data) != net.IPv4len {
+			return "", errDNSMessage
+		}
+		return net.IP(data).String(), nil
+	case dnsTypes["AAAA"]:
+		if len(data) != net.IPv6len {
+			return "", errDNSMessage
+		}
+		return net.IP(data).String(), nil
+	case dnsTypes["CNAME"], dnsTypes["NS"]:
+		name, _, err := readDNSName(msg, start)
+		return name, err
+	case dnsTypes["MX"]:
+		if len(data) < 3 {
+			return "", errDNSMessage
+		}
+		name, _, err := readDNSName(msg, start+2) // after preference
+		return name, err
+	case dnsTypes["TXT"]:
+		var sb strings.Builder
+		for i := 0; i < len(data); {
+			n := int(data[i])
+			if i+1+n > len(data) {
+				return "", errDNSMessage
+			}
+			sb.Write(data[i+1 : i+1+n])
+			i += 1 + n
+		}
+		return sb.String(), nil
+	}
+	return "", fmt.Errorf("unsupported record type %d", rtype)
+}
+
+// readDNSName reads a possibly compressed name at off and returns it (with a
+// trailing dot) and the offset after it
+func readDNSName(msg []byte, off int) (string, int, error) {
+	var sb strings.Builder
+	next := -1 // offset after the name where it started, once a pointer was followed
+	for jumps := 0; ; {
+		if off >= len(msg) {
+			return "", 0, errDNSMessage
+		}
+		n := int(msg[off])
+		switch n & 0xC0 {
+		case 0x00:
+			if n == 0 {
+				if next < 0 {
+					next = off + 1
+				}
+				if sb.Len() == 0 {
+					sb.WriteByte('.')
+				}
+				return sb.String(), next, nil
+			}
+			if off+1+n > len(msg) {
+				return "", 0, errDNSMessage
+			}
+			sb.Write(msg[off+1 : off+1+n])
+			sb.WriteByte('.')
+			off += 1 + n
+		case 0xC0:
+			if off+2 > len(msg) {
+				return "", 0, errDNSMessage
+			}
+			if jumps++; jumps > dnsMaxPointer {
+				return "", 0, fmt.Errorf("%w: compression loop", errDNSMessage)
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3FFF)
+		default:
+			return "", 0, errDNSMessage
+		}
+	}
+}
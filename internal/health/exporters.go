@@ -0,0 +1,233 @@
+package health
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/config"
+	"github.com/scinfra-pro/scinfra-bot/internal/prometheus"
+)
+
+// Exporter metric names (keys of query templates)
+const (
+	MetricUp          = "up"          // 1 if the exporter target is up
+	MetricCPU         = "cpu"         // CPU usage, 0-100%
+	MetricCPUCores    = "cpu_cores"   // CPU core count
+	MetricMemTotal    = "mem_total"   // Memory size, bytes
+	MetricMemUsed     = "mem_used"    // Used memory, bytes
+	MetricDiskSize    = "disk_size"   // Root filesystem size, bytes
+	MetricDiskUsed    = "disk_used"   // Root filesystem used, bytes
+	MetricUptime      = "uptime"      // Seconds since boot
+	MetricNetRx       = "net_rx"      // Received bytes per second
+	MetricNetTx       = "net_tx"      // Transmitted bytes per second
+	MetricFilesystems = "filesystems" // Usage per filesystem, 0-100%
+	MetricDiskFill    = "disk_fill"   // Seconds until a filesystem is full at the current trend, per filesystem
+)
+
+// exporterMetrics are the metric names a query template can define
+var exporterMetrics = map[string]bool{
+	MetricUp: true, MetricCPU: true, MetricCPUCores: true,
+	MetricMemTotal: true, MetricMemUsed: true,
+	MetricDiskSize: true, MetricDiskUsed: true,
+	MetricUptime: true, MetricNetRx: true, MetricNetTx: true,
+	MetricFilesystems: true, MetricDiskFill: true,
+}
+
+// DefaultRateWindow is the rate() window of health queries
+const DefaultRateWindow = 5 * time.Minute
+
+// DiskTrendWindow is the range predict_linear fits the disk usage trend over
+const DiskTrendWindow = 6 * time.Hour
+
+// FilesystemLabels name the filesystem of per-filesystem series (node_exporter,
+// windows_exporter, cAdvisor)
+var FilesystemLabels = []string{"mountpoint", "volume", "device"}
+
+// Exporter holds the query templates of an exporter type
+// Templates return one series per instance (filesystems: per instance and
+// filesystem) and may use these placeholders:
+//
+//	$job          job matcher, e.g. job="node"
+//	$instance     instance matcher, e.g. instance=~"10\.0\.1\.11(:[0-9]+)?"
+//	$window       rate() window, e.g. 5m
+//	$trend_window predict_linear() range of disk_fill, e.g. 360m
+//	$net_devices  regex of virtual network interfaces
+//	$pseudo_fs    regex of pseudo filesystem types
+type Exporter struct {
+	Name    string
+	Job     string
+	queries map[string]string // metric name -> template
+}
+
+// builtinExporters are the query templates shipped with the bot
+var builtinExporters = map[string]config.ExporterConfig{
+	config.ExporterNode: {
+		Job: "node",
+		Queries: map[string]string{
+			MetricUp:       `up{$job,$instance}`,
+			MetricCPU:      `100 - avg by (instance) (rate(node_cpu_seconds_total{mode="idle",$instance}[$window]))*100`,
+			MetricCPUCores: `count by (instance) (node_cpu_seconds_total{mode="idle",$instance})`,
+			MetricMemTotal: `max by (instance) (node_memory_MemTotal_bytes{$instance})`,
+			MetricMemUsed: `max by (instance) (node_memory_MemTotal_bytes{$instance}` +
+				` - node_memory_MemAvailable_bytes{$instance})`,
+			MetricDiskSize: `max by (instance) (node_filesystem_size_bytes{mountpoint="/",$instance})`,
+			MetricDiskUsed: `max by (instance) (node_filesystem_size_bytes{mountpoint="/",$instance}` +
+				` - node_filesystem_avail_bytes{mountpoint="/",$instance})`,
+			MetricUptime: `max by (instance) (node_time_seconds{$instance} - node_boot_time_seconds{$instance})`,
+			MetricNetRx:  `sum by (instance) (rate(node_network_receive_bytes_total{device!~"$net_devices",$instance}[$window]))`,
+			MetricNetTx:  `sum by (instance) (rate(node_network_transmit_bytes_total{device!~"$net_devices",$instance}[$window]))`,
+			MetricFilesystems: `max by (instance, mountpoint) (100 * (1 - node_filesystem_avail_bytes{fstype!~"$pseudo_fs",$instance}` +
+				` / node_filesystem_size_bytes{fstype!~"$pseudo_fs",$instance}))`,
+			MetricDiskFill: fillQuery("instance, mountpoint", `node_filesystem_avail_bytes{fstype!~"$pseudo_fs",$instance}`),
+		},
+	},
+	config.ExporterWindows: {
+		Job: "windows",
+		Queries: map[string]string{
+			MetricUp:       `up{$job,$instance}`,
+			MetricCPU:      `100 - avg by (instance) (rate(windows_cpu_time_total{mode="idle",$instance}[$window]))*100`,
+			MetricCPUCores: `count by (instance) (windows_cpu_time_total{mode="idle",$instance})`,
+			MetricMemTotal: `max by (instance) (windows_cs_physical_memory_bytes{$instance})`,
+			MetricMemUsed: `max by (instance) (windows_cs_physical_memory_bytes{$instance})` +
+				` - max by (instance) (windows_os_physical_memory_free_bytes{$instance})`,
+			MetricDiskSize: `max by (instance) (windows_logical_disk_size_bytes{volume="C:",$instance})`,
+			MetricDiskUsed: `max by (instance) (windows_logical_disk_size_bytes{volume="C:",$instance}` +
+				` - windows_logical_disk_free_bytes{volume="C:",$instance})`,
+			MetricUptime: `max by (instance) (time() - windows_system_system_up_time{$instance})`,
+			MetricNetRx:  `sum by (instance) (rate(windows_net_bytes_received_total{$instance}[$window]))`,
+			MetricNetTx:  `sum by (instance) (rate(windows_net_bytes_sent_total{$instance}[$window]))`,
+			MetricFilesystems: `max by (instance, volume) (100 * (1 - windows_logical_disk_free_bytes{$instance}` +
+				` / windows_logical_disk_size_bytes{$instance}))`,
+			MetricDiskFill: fillQuery("instance, volume", `windows_logical_disk_free_bytes{$instance}`),
+		},
+	},
+	config.ExporterCAdvisor: {
+		Job: "cadvisor",
+		Queries: map[string]string{
+			MetricUp: `up{$job,$instance}`,
+			MetricCPU: `100 * sum by (instance) (rate(container_cpu_usage_seconds_total{id="/",$instance}[$window]))` +
+				` / max by (instance) (machine_cpu_cores{$instance})`,
+			MetricCPUCores: `max by (instance) (machine_cpu_cores{$instance})`,
+			MetricMemTotal: `max by (instance) (machine_memory_bytes{$instance})`,
+			MetricMemUsed:  `max by (instance) (container_memory_working_set_bytes{id="/",$instance})`,
+			MetricDiskSize: `max by (instance) (container_fs_limit_bytes{id="/",$instance})`,
+			MetricDiskUsed: `max by (instance) (container_fs_usage_bytes{id="/",$instance})`,
+			MetricUptime:   `max by (instance) (time() - container_start_time_seconds{id="/",$instance})`,
+			MetricNetRx: `sum by (instance) (rate(container_network_receive_bytes_total{id="/",interface!~"$net_devices",$instance}` +
+				`[$window]))`,
+			MetricNetTx: `sum by (instance) (rate(container_network_transmit_bytes_total{id="/",interface!~"$net_devices",$instance}` +
+				`[$window]))`,
+			MetricFilesystems: `max by (instance, device) (100 * container_fs_usage_bytes{id="/",$instance}` +
+				` / container_fs_limit_bytes{id="/",$instance})`,
+		},
+	},
+}
+
+// fillQuery returns a disk_fill template: the seconds until the free space
+// selector reaches zero, from predict_linear over $trend_window
+// Only filesystems that are filling up have a series
+func fillQuery(by, free string) string {
+	now := fmt.Sprintf("predict_linear(%s[$trend_window], 0)", free)
+	next := fmt.Sprintf("predict_linear(%s[$trend_window], 1)", free)
+	return fmt.Sprintf("min by (%s) (%s / (%s - %s)) > 0", by, now, now, next)
+}
+
+// placeholderPattern matches template placeholders such as $instance
+var placeholderPattern = regexp.MustCompile(`\$[a-z][a-z_]*`)
+
+// knownPlaceholders are the placeholders Query replaces
+var knownPlaceholders = map[string]bool{
+	"$job": true, "$instance": true, "$window": true, "$trend_window": true,
+	"$net_devices": true, "$pseudo_fs": true,
+}
+
+// buildExporters merges the built-in exporters with config overrides
+// A query set to "" removes the metric; new exporter types must define up
+func buildExporters(overrides map[string]config.ExporterConfig) (map[string]*Exporter, error) {
+	exporters := make(map[string]*Exporter)
+	for name, cfg := range builtinExporters {
+		exporters[name] = newExporter(name, cfg)
+	}
+
+	for name, cfg := range overrides {
+		e, ok := exporters[name]
+		if !ok {
+			e = &Exporter{Name: name, queries: make(map[string]string)}
+			exporters[name] = e
+		}
+		if cfg.Job != "" {
+			e.Job = cfg.Job
+		}
+		for metric, tmpl := range cfg.Queries {
+			if !exporterMetrics[metric] {
+				return nil, fmt.Errorf("exporter %s: unknown metric %q", name, metric)
+			}
+			if tmpl == "" {
+				delete(e.queries, metric)
+				continue
+			}
+			for _, p := range placeholderPattern.FindAllString(tmpl, -1) {
+				if !knownPlaceholders[p] {
+					return nil, fmt.Errorf("exporter %s: %s query: unknown placeholder %s", name, metric, p)
+				}
+			}
+			e.queries[metric] = tmpl
+		}
+		if e.Job == "" {
+			return nil, fmt.Errorf("exporter %s: job is required", name)
+		}
+		if _, ok := e.queries[MetricUp]; !ok {
+			return nil, fmt.Errorf("exporter %s: %s query is required", name, MetricUp)
+		}
+	}
+
+	return exporters, nil
+}
+
+// newExporter creates an exporter from a config (queries are copied)
+func newExporter(name string, cfg config.ExporterConfig) *Exporter {
+	queries := make(map[string]string, len(cfg.Queries))
+	for metric, tmpl := range cfg.Queries {
+		queries[metric] = tmpl
+	}
+	return &Exporter{Name: name, Job: cfg.Job, queries: queries}
+}
+
+// Query returns the PromQL of a metric for an instance, or false if the
+// exporter has no query for it. An empty instance matches all instances.
+func (e *Exporter) Query(metric, instance string, window time.Duration) (string, bool) {
+	tmpl, ok := e.queries[metric]
+	if !ok {
+		return "", false
+	}
+	r := strings.NewReplacer(
+		"$job", prometheus.Matcher("job", prometheus.MatchEqual, e.Job),
+		"$instance", prometheus.InstanceMatcher(instance),
+		"$window", formatWindow(window),
+		"$trend_window", formatWindow(DiskTrendWindow),
+		"$net_devices", prometheus.VirtualNetDevices,
+		"$pseudo_fs", prometheus.PseudoFSTypes,
+	)
+	return r.Replace(tmpl), true
+}
+
+// Metrics returns the metric names the exporter has queries for, sorted
+func (e *Exporter) Metrics() []string {
+	metrics := make([]string, 0, len(e.queries))
+	for metric := range e.queries {
+		metrics = append(metrics, metric)
+	}
+	sort.Strings(metrics)
+	return metrics
+}
+
+// formatWindow formats a rate() window as a PromQL duration ("5m", "300s")
+func formatWindow(d time.Duration) string {
+	if d%time.Minute == 0 {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%ds", int(d.Seconds()))
+}
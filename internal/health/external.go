@@ -0,0 +1,391 @@
+package health
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// External checks are configured as URL strings, assertions go into the fragment:
+//
+//	https://example.com/health#status=200&body=OK&header=Content-Type:json&max_latency=500ms
+//	https://example.com#verify_chain=true
+//	tcp://1.2.3.4:443
+//	dns://1.1.1.1/example.com#type=A&expect=1.2.3.4
+//	udp://1.2.3.4:51820#send=ping&expect=pong
+//
+// Fragment values are URL-encoded (use %26 for "&" in a regex).
+
+const (
+	externalTimeout = 10 * time.Second // default per-check timeout
+	udpReplyTimeout = 3 * time.Second  // default wait for a UDP reply
+	maxBodyBytes    = 1 << 20          // body read limit for HTTP assertions
+)
+
+// ExternalCheckResult is the result of a single external check
+type ExternalCheckResult struct {
+	Target  string        // check URL as configured
+	OK      bool          // all assertions passed
+	Latency time.Duration // time until the response (or failure)
+	Error   string        // first failed assertion or connection error
+	Cert    *CertInfo     // TLS certificate (https:// checks only)
+}
+
+// Label returns the check URL without assertions, for display
+func (r *ExternalCheckResult) Label() string {
+	if i := strings.IndexByte(r.Target, '#'); i >= 0 {
+		return r.Target[:i]
+	}
+	return r.Target
+}
+
+// CertInfo describes the leaf TLS certificate of an external HTTPS check
+type CertInfo struct {
+	NotAfter   time.Time
+	Issuer     string   // issuer common name (or organization)
+	DNSNames   []string // subject alternative names
+	ChainValid bool     // chain verifies against system roots for the final host after redirects
+	ChainError string   // verification error if chain is invalid
+}
+
+// DaysLeft returns whole days until the certificate expires (negative if expired)
+func (ci *CertInfo) DaysLeft() int {
+	return int(math.Floor(time.Until(ci.NotAfter).Hours() / 24))
+}
+
+// checkExternal runs a single external check and its assertions
+func (c *Checker) checkExternal(ctx context.Context, target string) ExternalCheckResult {
+	return runCheck(ctx, c.httpClient, target)
+}
+
+// runCheck runs a check URL with its assertions using the given HTTP client
+func runCheck(ctx context.Context, client *http.Client, target string) ExternalCheckResult {
+	result := ExternalCheckResult{Target: target}
+
+	u, opts, err := parseCheckURL(target)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	start := time.Now()
+	switch u.Scheme {
+	case "tcp":
+		err = checkTCP(ctx, u.Host)
+	case "udp":
+		err = checkUDP(ctx, u.Host, opts)
+	case "dns":
+		err = checkDNS(ctx, u, opts)
+	case "http", "https":
+		result.Cert, err = checkHTTP(ctx, client, u, opts)
+	default:
+		err = fmt.Errorf("unsupported check type %q", u.Scheme)
+	}
+	result.Latency = time.Since(start)
+
+	// Latency assertion applies to every check type
+	if err == nil && opts.Get("max_latency") != "" {
+		maxLatency, perr := time.ParseDuration(opts.Get("max_latency"))
+		switch {
+		case perr != nil:
+			err = fmt.Errorf("invalid max_latency: %w", perr)
+		case result.Latency > maxLatency:
+			err = fmt.Errorf("latency %dms > %dms", result.Latency.Milliseconds(), maxLatency.Milliseconds())
+		}
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.OK = true
+	return result
+}
+
+// parseCheckURL splits a check URL into the target and its fragment assertions
+func parseCheckURL(target string) (*url.URL, url.Values, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	opts, err := url.ParseQuery(u.EscapedFragment())
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid assertions: %w", err)
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	if u.Host == "" {
+		return nil, nil, fmt.Errorf("invalid URL: missing host")
+	}
+	return u, opts, nil
+}
+
+// checkHTTP performs an HTTP/HTTPS check
+// Supported assertions: status, body, body_regex, header (repeatable, "Name:value"),
+// verify_chain (HTTPS only: fail if the certificate chain is invalid)
+// Without status assertion any 2xx/3xx is a success. For HTTPS the leaf
+// certificate is returned even if an assertion fails.
+func checkHTTP(ctx context.Context, client *http.Client, u *url.URL, opts url.Values) (*CertInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, externalTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// resp.Request is the last request, so the chain is verified for the
+	// host that served the response after redirects
+	var cert *CertInfo
+	if resp.TLS != nil {
+		cert = inspectCert(resp.TLS.PeerCertificates, resp.Request.URL.Hostname())
+	}
+
+	// Certificate chain (reported only, unless asserted)
+	if v := opts.Get("verify_chain"); v != "" {
+		verify, err := strconv.ParseBool(v)
+		if err != nil {
+			return cert, fmt.Errorf("invalid verify_chain: %q", v)
+		}
+		if verify && cert != nil && !cert.ChainValid {
+			return cert, fmt.Errorf("cert chain invalid: %s", cert.ChainError)
+		}
+	}
+
+	// Status code
+	if expected := opts.Get("status"); expected != "" {
+		if !statusMatches(resp.StatusCode, expected) {
+			return cert, fmt.Errorf("HTTP %d, expected %s", resp.StatusCode, expected)
+		}
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return cert, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	// Headers
+	for _, h := range opts["header"] {
+		name, want, _ := strings.Cut(h, ":")
+		name = strings.TrimSpace(name)
+		want = strings.TrimSpace(want)
+		got, present := resp.Header[http.CanonicalHeaderKey(name)]
+		if !present {
+			return cert, fmt.Errorf("header %s missing", name)
+		}
+		if want != "" && !strings.Contains(strings.Join(got, ", "), want) {
+			return cert, fmt.Errorf("header %s: %q does not contain %q", name, strings.Join(got, ", "), want)
+		}
+	}
+
+	// Body
+	substr, pattern := opts.Get("body"), opts.Get("body_regex")
+	if substr == "" && pattern == "" {
+		return cert, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	if err != nil {
+		return cert, fmt.Errorf("read body: %w", err)
+	}
+	if substr != "" && !strings.Contains(string(body), substr) {
+		return cert, fmt.Errorf("body does not contain %q", substr)
+	}
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return cert, fmt.Errorf("invalid body_regex: %w", err)
+		}
+		if !re.Match(body) {
+			return cert, fmt.Errorf("body does not match /%s/", pattern)
+		}
+	}
+
+	return cert, nil
+}
+
+// statusMatches checks a status code against "200", "200,204" or "2xx"
+func statusMatches(code int, expected string) bool {
+	for _, s := range strings.Split(expected, ",") {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if len(s) == 3 && strings.HasSuffix(s, "xx") {
+			if strconv.Itoa(code/100) == s[:1] {
+				return true
+			}
+			continue
+		}
+		if n, err := strconv.Atoi(s); err == nil && n == code {
+			return true
+		}
+	}
+	return false
+}
+
+// inspectCert extracts expiry, issuer and SANs from the peer chain and
+// verifies it (the HTTP client skips verification so that an invalid
+// certificate is reported instead of failing the check)
+func inspectCert(chain []*x509.Certificate, host string) *CertInfo {
+	if len(chain) == 0 {
+		return nil
+	}
+	leaf := chain[0]
+
+	info := &CertInfo{
+		NotAfter: leaf.NotAfter,
+		Issuer:   leaf.Issuer.CommonName,
+		DNSNames: leaf.DNSNames,
+	}
+	if info.Issuer == "" && len(leaf.Issuer.Organization) > 0 {
+		info.Issuer = leaf.Issuer.Organization[0]
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := leaf.Verify(x509.VerifyOptions{
+		DNSName:       host,
+		Intermediates: intermediates,
+	})
+	if err != nil {
+		info.ChainError = err.Error()
+	} else {
+		info.ChainValid = true
+	}
+
+	return info
+}
+
+// checkTCP performs a TCP connection check
+func checkTCP(ctx context.Context, addr string) error {
+	dialer := &net.Dialer{Timeout: externalTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("connection failed: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	return nil
+}
+
+// checkUDP sends a payload and waits for a reply
+// Assertions: send (payload, default empty), send_hex, expect (reply substring), timeout
+func checkUDP(ctx context.Context, addr string, opts url.Values) error {
+	payload := []byte(opts.Get("send"))
+	if hexPayload := opts.Get("send_hex"); hexPayload != "" {
+		var err error
+		hexPayload = strings.NewReplacer(" ", "", ":", "").Replace(hexPayload)
+		if payload, err = hex.DecodeString(hexPayload); err != nil {
+			return fmt.Errorf("invalid send_hex: %w", err)
+		}
+	}
+
+	wait := udpReplyTimeout
+	if t := opts.Get("timeout"); t != "" {
+		d, err := time.ParseDuration(t)
+		if err != nil {
+			return fmt.Errorf("invalid timeout: %w", err)
+		}
+		wait = d
+	}
+
+	dialer := &net.Dialer{Timeout: externalTimeout}
+	conn, err := dialer.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return fmt.Errorf("connection failed: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	deadline := time.Now().Add(wait)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+
+	if _, err := conn.Write(payload); err != nil {
+		return fmt.Errorf("send failed: %w", err)
+	}
+
+	buf := make([]byte, 64*1024)
+	n, err := conn.Read(buf)
+	if err != nil {
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			return fmt.Errorf("no reply within %s", wait)
+		}
+		return fmt.Errorf("no reply: %w", err)
+	}
+
+	if expect := opts.Get("expect"); expect != "" && !strings.Contains(string(buf[:n]), expect) {
+		return fmt.Errorf("reply does not contain %q", expect)
+	}
+	return nil
+}
+
+// checkDNS resolves a name against the given server: dns://server[:port]/name
+// Assertions: type (A, AAAA, CNAME, MX, NS, TXT; default A), expect (repeatable
+// or comma-separated, every value must be among the answers)
+func checkDNS(ctx context.Context, u *url.URL, opts url.Values) error {
+	name := strings.TrimPrefix(u.Path, "/")
+	if name == "" {
+		return fmt.Errorf("missing name to resolve (dns://server/name)")
+	}
+
+	server := u.Host
+	if u.Port() == "" {
+		server = net.JoinHostPort(u.Hostname(), "53")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, externalTimeout)
+	defer cancel()
+
+	recordType := strings.ToUpper(opts.Get("type"))
+	if recordType == "" {
+		recordType = "A"
+	}
+
+	answers, err := queryDNS(ctx, server, recordType, name)
+	if err != nil {
+		return fmt.Errorf("resolve %s %s: %w", recordType, name, err)
+	}
+	if len(answers) == 0 {
+		return fmt.Errorf("no %s records for %s", recordType, name)
+	}
+
+	for _, expect := range opts["expect"] {
+		for _, want := range strings.Split(expect, ",") {
+			want = strings.TrimSuffix(strings.TrimSpace(want), ".")
+			if want == "" {
+				continue
+			}
+			if !containsFold(answers, want) {
+				return fmt.Errorf("%s %s: expected %s, got %s", recordType, name, want, strings.Join(answers, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// containsFold reports whether values contain s (case-insensitive)
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/prometheus"
+)
+
+// fleetTTL is how long fleet query results are shared between probes
+// Probes of one refresh run within this window, so a refresh costs one set of queries
+const fleetTTL = 10 * time.Second
+
+// fleetServiceUp is the fleet result of `up` for all jobs (service checks)
+const fleetServiceUp = "service_up"
+
+// ErrNoQuery is returned for metrics the exporter has no query for
+var ErrNoQuery = errors.New("no query for metric")
+
+// FleetMetrics holds the results of the fleet queries of one data source and exporter
+type FleetMetrics struct {
+	results map[string][]prometheus.QueryResult // metric name -> series
+	errs    map[string]error                    // metric name -> query error
+	failed  error                               // error of all metrics (e.g. the caller stopped waiting)
+	at      time.Time
+}
+
+// collectFleet runs the queries of an exporter for all instances of a data
+// source in parallel, plus `up` of all jobs for service checks
+func collectFleet(ctx context.Context, client *prometheus.Client, exporter *Exporter) *FleetMetrics {
+	m := &FleetMetrics{
+		results: make(map[string][]prometheus.QueryResult),
+		errs:    make(map[string]error),
+		at:      time.Now(),
+	}
+
+	queries := map[string]string{fleetServiceUp: "up"}
+	for _, metric := range exporter.Metrics() {
+		queries[metric], _ = exporter.Query(metric, "", DefaultRateWindow)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, query := range queries {
+		wg.Add(1)
+		go func(name, query string) {
+			defer wg.Done()
+			results, err := client.QueryContext(ctx, query)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				m.errs[name] = err
+				return
+			}
+			m.results[name] = results
+		}(name, query)
+	}
+	wg.Wait()
+
+	return m
+}
+
+// err returns the query error of a metric
+func (m *FleetMetrics) err(name string) error {
+	if m.failed != nil {
+		return m.failed
+	}
+	return m.errs[name]
+}
+
+// Value returns the value of a metric for an instance
+// Returns ErrNoQuery if the exporter has no query for the metric, or an error
+// if the query failed or has no series for the instance
+func (m *FleetMetrics) Value(name, instance string) (float64, error) {
+	if err := m.err(name); err != nil {
+		return 0, err
+	}
+	if _, ok := m.results[name]; !ok {
+		return 0, fmt.Errorf("%s: %w", name, ErrNoQuery)
+	}
+	for _, r := range m.results[name] {
+		if matchInstance(r.Instance(), instance) {
+			return r.Value, nil
+		}
+	}
+	return 0, fmt.Errorf("no %s data for instance %s", name, instance)
+}
+
+// Series returns all series of a metric for an instance (e.g. one per filesystem)
+// Returns ErrNoQuery if the exporter has no query for the metric, or an error
+// if the query failed; no series is not an error
+func (m *FleetMetrics) Series(name, instance string) ([]prometheus.QueryResult, error) {
+	if err := m.err(name); err != nil {
+		return nil, err
+	}
+	if _, ok := m.results[name]; !ok {
+		return nil, fmt.Errorf("%s: %w", name, ErrNoQuery)
+	}
+	var series []prometheus.QueryResult
+	for _, r := range m.results[name] {
+		if matchInstance(r.Instance(), instance) {
+			series = append(series, r)
+		}
+	}
+	return series, nil
+}
+
+// ServiceUp reports whether the `up` series of a job is 1
+// An empty instance matches any instance of the job
+func (m *FleetMetrics) ServiceUp(job, instance string) (bool, error) {
+	if err := m.err(fleetServiceUp); err != nil {
+		return false, err
+	}
+	for _, r := range m.results[fleetServiceUp] {
+		if r.Metric["job"] != job {
+			continue
+		}
+		if instance == "" || matchInstance(r.Instance(), instance) {
+			return r.Value == 1, nil
+		}
+	}
+	return false, fmt.Errorf("no up series for job %s", job)
+}
+
+// matchInstance reports whether an instance label belongs to a configured
+// instance: exact match ("edge-gateway") or the same host with a port
+// ("10.0.1.11" matches "10.0.1.11:9100")
+func matchInstance(label, instance string) bool {
+	return label == instance || strings.HasPrefix(label, instance+":")
+}
+
+// fleetCache shares fleet metrics between the probes of a refresh
+// Concurrent callers for the same data source and exporter wait for one collection
+type fleetCache struct {
+	mu      sync.Mutex
+	entries map[string]*fleetEntry // key is "datasource/exporter"
+}
+
+// fleetEntry is a collection in progress or done
+type fleetEntry struct {
+	done    chan struct{}
+	metrics *FleetMetrics
+}
+
+// get returns fresh fleet metrics of a data source and exporter, collecting them if needed
+// The collection runs on a context of the cache bounded by the data source
+// timeout, so a caller that gives up (ctx done) does not fail it for the others
+func (f *fleetCache) get(ctx context.Context, datasource string, client *prometheus.Client, exporter *Exporter) *FleetMetrics {
+	name := datasource + "/" + exporter.Name
+	f.mu.Lock()
+	if f.entries == nil {
+		f.entries = make(map[string]*fleetEntry)
+	}
+	entry, ok := f.entries[name]
+	if ok {
+		select {
+		case <-entry.done:
+			if time.Since(entry.metrics.at) >= fleetTTL {
+				ok = false // expired
+			}
+		default: // in progress
+		}
+	}
+	if !ok {
+		entry = &fleetEntry{done: make(chan struct{})}
+		f.entries[name] = entry
+		go f.collect(name, entry, client, exporter)
+	}
+	f.mu.Unlock()
+
+	select {
+	case <-entry.done:
+		return entry.metrics
+	case <-ctx.Done():
+		return &FleetMetrics{failed: ctx.Err(), at: time.Now()}
+	}
+}
+
+// collect runs the fleet queries of an entry
+// A collection that hit its deadline is not cached - the next caller retries
+func (f *fleetCache) collect(name string, entry *fleetEntry, client *prometheus.Client, exporter *Exporter) {
+	ctx, cancel := context.WithTimeout(context.Background(), client.Timeout())
+	defer cancel()
+
+	entry.metrics = collectFleet(ctx, client, exporter)
+	close(entry.done)
+
+	if ctx.Err() != nil {
+		f.mu.Lock()
+		if f.entries[name] == entry {
+			delete(f.entries, name)
+		}
+		f.mu.Unlock()
+	}
+}
@@ -0,0 +1,372 @@
+package health
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/config"
+)
+
+// Notifier sends alert notifications (implemented by the Telegram bot)
+type Notifier interface {
+	SendNotification(text string) error
+}
+
+// Silencer reports whether notifications for a server or service are suppressed
+// (service is empty for server-level notifications)
+type Silencer interface {
+	IsSilenced(serverID, service string) bool
+}
+
+// Transition is a confirmed level change of a server or service
+type Transition struct {
+	ServerID string
+	Service  string // empty for the server itself
+	Name     string
+	From     StatusLevel // empty for the baseline observation on start
+	To       StatusLevel
+	Reasons  []string
+	Since    time.Time // when the new level was first observed
+}
+
+// TransitionRecorder records confirmed transitions (e.g. incident history)
+// Transitions are recorded even while notifications are silenced or paused
+type TransitionRecorder interface {
+	RecordTransition(t Transition)
+}
+
+// Sampler receives the results of every poll (e.g. for availability reports)
+type Sampler interface {
+	RecordSample(statuses []*ServerStatus, at time.Time)
+}
+
+// Monitor polls health in the background and notifies on state transitions
+// With alerts disabled it still polls for the recorder and sampler
+type Monitor struct {
+	checker  *Checker
+	notifier Notifier
+	cfg      config.AlertsConfig
+	silencer Silencer           // optional
+	recorder TransitionRecorder // optional
+	sampler  Sampler            // optional
+
+	states map[string]*targetState // key: serverID or serverID/service
+	mu     sync.Mutex
+
+	started  bool
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// targetState tracks the alerting state of a server or service
+type targetState struct {
+	serverID string
+	service  string // empty for the server itself
+	name     string // "web-server" or "web-server / Nginx"
+
+	level        StatusLevel // confirmed level (last notified)
+	pending      StatusLevel // observed level waiting for debounce
+	pendingSince time.Time
+	outageSince  time.Time // when confirmed level left "up"
+
+	changes  []time.Time // confirmed transitions within flap window
+	flapping bool
+}
+
+// NewMonitor creates a new background health monitor
+func NewMonitor(checker *Checker, notifier Notifier, cfg config.AlertsConfig) *Monitor {
+	return &Monitor{
+		checker:  checker,
+		notifier: notifier,
+		cfg:      cfg,
+		states:   make(map[string]*targetState),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// SetSilencer sets the silence source for suppressing notifications
+// Must be called before Start
+func (m *Monitor) SetSilencer(s Silencer) {
+	m.silencer = s
+}
+
+// SetRecorder sets the recorder for confirmed transitions
+// Must be called before Start
+func (m *Monitor) SetRecorder(r TransitionRecorder) {
+	m.recorder = r
+}
+
+// SetSampler sets the receiver of poll results
+// Must be called before Start
+func (m *Monitor) SetSampler(s Sampler) {
+	m.sampler = s
+}
+
+// Start launches the polling loop in background until Stop is called
+func (m *Monitor) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.started {
+		return
+	}
+	m.started = true
+	go m.run()
+}
+
+// run is the polling loop
+func (m *Monitor) run() {
+	defer close(m.done)
+
+	log.Printf("Health monitor started (interval %s, min duration %s, alerts %t)", m.cfg.Interval, m.cfg.MinDuration, m.cfg.Enabled)
+
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+
+	m.poll()
+	for {
+		select {
+		case <-ticker.C:
+			m.poll()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// Stop stops the polling loop and waits for it to exit (safe to call more than once)
+func (m *Monitor) Stop() {
+	m.mu.Lock()
+	started := m.started
+	m.mu.Unlock()
+
+	m.stopOnce.Do(func() { close(m.stop) })
+	if started {
+		<-m.done
+	}
+}
+
+// poll runs a health check and processes state transitions
+func (m *Monitor) poll() {
+	statuses, err := m.checker.CheckAllForce()
+	if err != nil {
+		log.Printf("Health monitor: check failed: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, status := range statuses {
+		m.observeServer(status, now)
+	}
+	if m.sampler != nil {
+		m.sampler.RecordSample(statuses, now)
+	}
+}
+
+// observeServer processes server and service levels
+func (m *Monitor) observeServer(status *ServerStatus, now time.Time) {
+	// Critical services decide the server level and are alerted with the
+	// server; other services are alerted individually
+	server := *status
+	server.Services = nil
+	for _, svc := range status.Services {
+		if svc.Criticality == config.CriticalityCritical {
+			server.Services = append(server.Services, svc)
+		}
+	}
+	level, reasons := server.Evaluate()
+	m.observe(status.ID, status.ID, "", status.Name, level, reasons, now)
+
+	// Services of a down server are down too - the server alert covers them
+	if !status.IsUp {
+		return
+	}
+
+	current := make(map[string]bool, len(status.Services))
+	for _, svc := range status.Services {
+		if svc.Criticality == config.CriticalityCritical {
+			continue
+		}
+		level := StatusUp
+		if !svc.IsUp {
+			level = StatusDown
+		}
+		key := status.ID + "/" + svc.Name
+		current[key] = true
+		name := fmt.Sprintf("%s / %s", status.Name, svc.Name)
+		var details []string
+		if svc.Error != "" {
+			details = append(details, svc.Error)
+		}
+		m.observe(key, status.ID, svc.Name, name, level, details, now)
+	}
+	m.pruneServices(status.ID, current)
+}
+
+// pruneServices drops states of services a server no longer has
+func (m *Monitor) pruneServices(serverID string, current map[string]bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, st := range m.states {
+		if st.serverID == serverID && st.service != "" && !current[key] {
+			delete(m.states, key)
+		}
+	}
+}
+
+// observe applies debounce and flap detection to an observed level
+func (m *Monitor) observe(key, serverID, service, name string, observed StatusLevel, details []string, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st, ok := m.states[key]
+	if !ok {
+		// First observation is the baseline - no alert on bot start
+		st = &targetState{serverID: serverID, service: service, name: name, level: observed}
+		if observed != StatusUp {
+			st.outageSince = now
+		}
+		m.states[key] = st
+		m.record(st, "", details, now)
+		return
+	}
+
+	m.pruneChanges(st, now)
+
+	// Flapping ends once no transitions happened within the window
+	if st.flapping && len(st.changes) == 0 {
+		st.flapping = false
+		m.notify(st, fmt.Sprintf("✅ <b>%s</b> stopped flapping\nCurrent: %s %s",
+			html.EscapeString(st.name), levelIcon(st.level), st.level))
+	}
+
+	if observed == st.level {
+		st.pending = ""
+		return
+	}
+
+	// Debounce: new level must persist for MinDuration
+	if observed != st.pending {
+		st.pending = observed
+		st.pendingSince = now
+	}
+	if now.Sub(st.pendingSince) < m.cfg.MinDuration {
+		return
+	}
+
+	// Confirm transition
+	prev := st.level
+	st.level = observed
+	st.pending = ""
+	st.changes = append(st.changes, now)
+
+	var outage time.Duration
+	if prev == StatusUp {
+		st.outageSince = st.pendingSince
+	} else if observed == StatusUp && !st.outageSince.IsZero() {
+		outage = st.pendingSince.Sub(st.outageSince) // both ends exclude the debounce
+		st.outageSince = time.Time{}
+	}
+
+	log.Printf("Health monitor: %s %s → %s", st.name, prev, observed)
+	m.record(st, prev, details, st.pendingSince)
+
+	if st.flapping {
+		return // notifications paused while flapping
+	}
+	if len(st.changes) >= m.cfg.FlapThreshold {
+		st.flapping = true
+		m.notify(st, fmt.Sprintf("🔃 <b>%s</b> is flapping (%d state changes in %s)\nCurrent: %s %s\n<i>Notifications paused until stable</i>",
+			html.EscapeString(st.name), len(st.changes), FormatDuration(m.cfg.FlapWindow),
+			levelIcon(observed), observed))
+		return
+	}
+
+	m.notify(st, formatTransition(st.name, prev, observed, details, outage))
+}
+
+// pruneChanges drops transitions older than the flap window
+func (m *Monitor) pruneChanges(st *targetState, now time.Time) {
+	kept := st.changes[:0]
+	for _, t := range st.changes {
+		if now.Sub(t) < m.cfg.FlapWindow {
+			kept = append(kept, t)
+		}
+	}
+	st.changes = kept
+}
+
+// record passes the confirmed level of a target to the recorder
+func (m *Monitor) record(st *targetState, from StatusLevel, reasons []string, since time.Time) {
+	if m.recorder == nil {
+		return
+	}
+	m.recorder.RecordTransition(Transition{
+		ServerID: st.serverID,
+		Service:  st.service,
+		Name:     st.name,
+		From:     from,
+		To:       st.level,
+		Reasons:  reasons,
+		Since:    since,
+	})
+}
+
+// notify sends a notification for a target unless alerts are disabled or it
+// is silenced (errors are logged)
+func (m *Monitor) notify(st *targetState, text string) {
+	if !m.cfg.Enabled {
+		return
+	}
+	if m.silencer != nil && m.silencer.IsSilenced(st.serverID, st.service) {
+		log.Printf("Health monitor: %s notification suppressed (silenced)", st.name)
+		return
+	}
+	if err := m.notifier.SendNotification(text); err != nil {
+		log.Printf("Health monitor: failed to send notification: %v", err)
+	}
+}
+
+// formatTransition formats a state transition notification
+// details are the reasons for the new level (omitted on recovery)
+func formatTransition(name string, prev, level StatusLevel, details []string, outage time.Duration) string {
+	var text string
+	switch level {
+	case StatusUp:
+		text = fmt.Sprintf("🟢 <b>%s</b> recovered", html.EscapeString(name))
+		if outage > 0 {
+			text += fmt.Sprintf("\nOutage: %s", FormatDuration(outage))
+		}
+	case StatusDegraded:
+		text = fmt.Sprintf("🟡 <b>%s</b> is degraded\nWas: %s %s", html.EscapeString(name), levelIcon(prev), prev)
+	default:
+		text = fmt.Sprintf("🛑 <b>%s</b> is down\nWas: %s %s", html.EscapeString(name), levelIcon(prev), prev)
+	}
+
+	if level != StatusUp {
+		for _, detail := range details {
+			text += fmt.Sprintf("\n• <code>%s</code>", html.EscapeString(detail))
+		}
+	}
+	return text
+}
+
+// levelIcon returns the status icon for a level
+func levelIcon(level StatusLevel) string {
+	switch level {
+	case StatusUp:
+		return "🟢"
+	case StatusDegraded:
+		return "🟡"
+	case StatusDown:
+		return "🛑"
+	default:
+		return "⚪"
+	}
+}
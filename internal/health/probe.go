@@ -0,0 +1,70 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/config"
+)
+
+// Probe checks one aspect of a server and merges the result into its status
+//
+// Run returns an error only if the server itself is unreachable (the checker
+// then marks it down with the error as reason). Failed checks of something
+// running on the server are reported as services instead.
+type Probe interface {
+	Run(ctx context.Context, target ProbeTarget, status *ServerStatus) error
+}
+
+// ProbeFunc adapts a function to the Probe interface
+type ProbeFunc func(ctx context.Context, target ProbeTarget, status *ServerStatus) error
+
+// Run calls f(ctx, target, status)
+func (f ProbeFunc) Run(ctx context.Context, target ProbeTarget, status *ServerStatus) error {
+	return f(ctx, target, status)
+}
+
+// ProbeTarget is the server a probe runs against and the probe's own options
+type ProbeTarget struct {
+	Server *config.ServerConfig
+	Spec   config.ProbeConfig
+}
+
+// ProbeRegistry maps probe kinds to implementations
+type ProbeRegistry struct {
+	mu     sync.RWMutex
+	probes map[string]Probe
+}
+
+// NewProbeRegistry creates an empty probe registry
+func NewProbeRegistry() *ProbeRegistry {
+	return &ProbeRegistry{probes: make(map[string]Probe)}
+}
+
+// Register adds or replaces the probe for a kind
+func (r *ProbeRegistry) Register(kind string, probe Probe) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.probes[kind] = probe
+}
+
+// Get returns the probe for a kind
+func (r *ProbeRegistry) Get(kind string) (Probe, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	probe, ok := r.probes[kind]
+	return probe, ok
+}
+
+// Kinds returns registered probe kinds in sorted order
+func (r *ProbeRegistry) Kinds() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	kinds := make([]string, 0, len(r.probes))
+	for kind := range r.probes {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
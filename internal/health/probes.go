@@ -0,0 +1,423 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/config"
+	"github.com/scinfra-pro/scinfra-bot/internal/prometheus"
+	"github.com/scinfra-pro/scinfra-bot/internal/switchgate"
+)
+
+// registerBuiltinProbes registers the probe kinds shipped with the bot
+func (c *Checker) registerBuiltinProbes() {
+	c.probes.Register(config.ProbePrometheus, &PrometheusProbe{Fleet: c.fleetFor})
+	c.probes.Register(config.ProbeSwitchGate, &SwitchGateProbe{Config: c.config, Clients: c.switchGateClients})
+	c.probes.Register(config.ProbeSSHCommand, &SSHCommandProbe{Config: c.config, Clients: c.switchGateClients})
+	c.probes.Register(config.ProbeHTTP, &HTTPProbe{Client: c.httpClient})
+	c.probes.Register(config.ProbeTCP, &TCPProbe{})
+}
+
+// PrometheusProbe checks a server using exporter metrics from Prometheus
+// Metrics come from fleet-wide queries shared by all servers of a data source
+type PrometheusProbe struct {
+	// Fleet returns the fleet metrics of the server's data source
+	Fleet func(ctx context.Context, server *config.ServerConfig) *FleetMetrics
+}
+
+// Run implements Probe
+func (p *PrometheusProbe) Run(ctx context.Context, target ProbeTarget, status *ServerStatus) error {
+	server := target.Server
+	fleet := p.Fleet(ctx, server)
+	promInstance := prometheusInstance(server)
+
+	// Check if server is up via Prometheus
+	// Without an answer nothing is known about the host: degraded, not down
+	up, err := fleet.Value(MetricUp, promInstance)
+	if err != nil {
+		log.Printf("Health check: %s: up query failed: %v", server.ID, err)
+		status.CheckErrors = append(status.CheckErrors, "monitoring unavailable: Prometheus query failed")
+		return nil
+	}
+	isUp := up == 1
+
+	// Get metrics only if server is up
+	// Metrics the exporter has no query for are left empty
+	if isUp {
+		var failed []string
+		check := func(name string, err error) bool {
+			if err != nil && !errors.Is(err, ErrNoQuery) {
+				failed = append(failed, name)
+			}
+			return err == nil
+		}
+
+		// CPU
+		if cpu, err := fleet.Value(MetricCPU, promInstance); check("cpu", err) {
+			status.CPU = cpu
+		}
+
+		// Memory
+		if used, total, err := fleetUsage(fleet, MetricMemTotal, MetricMemUsed, promInstance); check("memory", err) {
+			status.Memory = used / total * 100
+			status.MemoryUsedGB = used / (1024 * 1024 * 1024)
+			status.MemoryTotalGB = total / (1024 * 1024 * 1024)
+		}
+
+		// Disk
+		if used, total, err := fleetUsage(fleet, MetricDiskSize, MetricDiskUsed, promInstance); check("disk", err) {
+			status.Disk = used / total * 100
+			status.DiskUsedGB = used / (1024 * 1024 * 1024)
+			status.DiskTotalGB = total / (1024 * 1024 * 1024)
+		}
+
+		// All filesystems (usage only, sizes are known for the root filesystem)
+		if series, err := fleet.Series(MetricFilesystems, promInstance); check("filesystems", err) {
+			status.Filesystems = filesystemStatuses(series)
+		}
+
+		// Time until filesystems fill up at the current trend
+		if series, err := fleet.Series(MetricDiskFill, promInstance); check("disk fill", err) {
+			status.DiskFill = diskFillForecasts(series)
+		}
+
+		// Uptime
+		if seconds, err := fleet.Value(MetricUptime, promInstance); err == nil {
+			status.Uptime = time.Duration(seconds) * time.Second
+		}
+
+		// CPU cores
+		if cores, err := fleet.Value(MetricCPUCores, promInstance); err == nil {
+			status.CPUCores = int(cores)
+		}
+
+		// Network
+		rx, rxErr := fleet.Value(MetricNetRx, promInstance)
+		tx, txErr := fleet.Value(MetricNetTx, promInstance)
+		if rxErr == nil && txErr == nil {
+			status.NetworkRxBytesPerSec = rx
+			status.NetworkTxBytesPerSec = tx
+		}
+
+		if len(failed) > 0 {
+			status.CheckErrors = append(status.CheckErrors, "Prometheus query failed: "+strings.Join(failed, ", "))
+		}
+	}
+
+	// Check services via Prometheus
+	for _, svc := range server.Services {
+		svcStatus := ServiceStatus{
+			Name: svc.Name,
+			Job:  svc.Job,
+			Port: svc.Port,
+		}
+
+		if svc.Job != "" {
+			// Check via Prometheus job
+			svcUp, err := fleet.ServiceUp(svc.Job, promInstance)
+			svcStatus.IsUp = svcUp
+			if err != nil {
+				svcStatus.Error = err.Error()
+			}
+		} else {
+			// If no job specified, assume running if server is up
+			svcStatus.IsUp = isUp
+		}
+
+		status.Services = append(status.Services, svcStatus)
+	}
+
+	if !isUp {
+		return fmt.Errorf("%s target down", server.ExporterType())
+	}
+	return nil
+}
+
+// fleetUsage returns used and total from a total and a used metric
+func fleetUsage(fleet *FleetMetrics, totalName, usedName, instance string) (used, total float64, err error) {
+	total, err = fleet.Value(totalName, instance)
+	if err != nil {
+		return 0, 0, err
+	}
+	used, err = fleet.Value(usedName, instance)
+	if err != nil {
+		return 0, 0, err
+	}
+	if total <= 0 {
+		return 0, 0, fmt.Errorf("%s is zero for instance %s", totalName, instance)
+	}
+	return used, total, nil
+}
+
+// filesystemStatuses converts filesystems series into statuses sorted by mountpoint
+func filesystemStatuses(series []prometheus.QueryResult) []FilesystemStatus {
+	filesystems := make([]FilesystemStatus, 0, len(series))
+	for _, r := range series {
+		if math.IsNaN(r.Value) {
+			continue
+		}
+		filesystems = append(filesystems, FilesystemStatus{
+			Mountpoint:  seriesFilesystem(r),
+			UsedPercent: r.Value,
+		})
+	}
+	sort.Slice(filesystems, func(i, j int) bool { return filesystems[i].Mountpoint < filesystems[j].Mountpoint })
+	return filesystems
+}
+
+// seriesFilesystem returns the filesystem named by a per-filesystem series ("/" if unnamed)
+func seriesFilesystem(r prometheus.QueryResult) string {
+	for _, label := range FilesystemLabels {
+		if v := r.Metric[label]; v != "" {
+			return v
+		}
+	}
+	return "/"
+}
+
+// diskFillForecasts converts disk_fill series into forecasts within
+// DiskFillHorizon, soonest first
+func diskFillForecasts(series []prometheus.QueryResult) []DiskFillForecast {
+	var forecasts []DiskFillForecast
+	for _, r := range series {
+		if math.IsNaN(r.Value) || r.Value <= 0 || r.Value > DiskFillHorizon.Seconds() {
+			continue // no trend, not filling up, or too far ahead to be meaningful (also +Inf)
+		}
+		forecasts = append(forecasts, DiskFillForecast{
+			Mountpoint: seriesFilesystem(r),
+			FillsIn:    time.Duration(r.Value * float64(time.Second)),
+		})
+	}
+	sort.Slice(forecasts, func(i, j int) bool { return forecasts[i].FillsIn < forecasts[j].FillsIn })
+	return forecasts
+}
+
+// prometheusInstance returns the instance label used in Prometheus queries
+// (prometheus_instance, or the server name if not set)
+func prometheusInstance(server *config.ServerConfig) string {
+	if server.PrometheusInstance != "" {
+		return server.PrometheusInstance
+	}
+	return server.Name
+}
+
+// SwitchGateProbe checks a remote VPS via the switch-gate API and node_exporter over SSH
+type SwitchGateProbe struct {
+	Config  *config.Config
+	Clients map[string]*switchgate.Client // key is upstream name
+}
+
+// Run implements Probe
+func (p *SwitchGateProbe) Run(ctx context.Context, target ProbeTarget, status *ServerStatus) error {
+	server := target.Server
+
+	upstreamKey := p.Config.GetUpstreamByIP(server.IP)
+	sgClient, ok := p.Clients[upstreamKey]
+	if upstreamKey == "" || !ok {
+		// No switch-gate client available
+		return fmt.Errorf("no switch-gate client for %s", server.IP)
+	}
+
+	// Get status from switch-gate API (this updates SSH statistics)
+	sgStatus, err := sgClient.GetStatusContext(ctx)
+
+	// Get SSH statistics AFTER the call (so it includes this request)
+	sshStats := sgClient.GetSSHStats()
+	status.SSHLatency = sshStats.LastLatency
+	status.SSHSuccessCount = sshStats.SuccessCount
+	status.SSHErrorCount = sshStats.ErrorCount
+	status.SSHLastError = sshStats.LastError
+	status.SSHLastErrorAt = sshStats.LastErrorAt
+
+	if err != nil {
+		// Add error as service status
+		status.Services = append(status.Services, ServiceStatus{
+			Name:  "switch-gate",
+			Port:  9090,
+			IsUp:  false,
+			Error: err.Error(),
+		})
+		return fmt.Errorf("switch-gate API: %w", err)
+	}
+
+	// Parse uptime from switch-gate status
+	if sgStatus.Uptime != "" {
+		if uptime, err := time.ParseDuration(sgStatus.Uptime); err == nil {
+			status.Uptime = uptime
+		}
+	}
+
+	// Get system metrics from node_exporter
+	if nodeMetrics, err := sgClient.GetNodeMetrics(ctx); err == nil {
+		// Memory
+		status.Memory = nodeMetrics.MemoryUsedPercent
+		status.MemoryUsedGB = nodeMetrics.MemoryUsedBytes / (1024 * 1024 * 1024)
+		status.MemoryTotalGB = nodeMetrics.MemoryTotalBytes / (1024 * 1024 * 1024)
+
+		// Disk
+		status.Disk = nodeMetrics.DiskUsedPercent
+		status.DiskUsedGB = nodeMetrics.DiskUsedBytes / (1024 * 1024 * 1024)
+		status.DiskTotalGB = nodeMetrics.DiskTotalBytes / (1024 * 1024 * 1024)
+		for _, fs := range nodeMetrics.Filesystems {
+			status.Filesystems = append(status.Filesystems, FilesystemStatus{
+				Mountpoint:  fs.Mountpoint,
+				UsedPercent: fs.UsedPercent,
+				UsedGB:      fs.UsedBytes / (1024 * 1024 * 1024),
+				TotalGB:     fs.SizeBytes / (1024 * 1024 * 1024),
+			})
+		}
+
+		// CPU - utilisation from node_cpu_seconds_total, normalised by core count
+		status.CPU = nodeMetrics.CPUUsedPercent
+		status.CPUCores = nodeMetrics.CPUCores
+
+		// Network
+		status.NetworkRxBytesPerSec = nodeMetrics.NetworkRxBytesPerSec
+		status.NetworkTxBytesPerSec = nodeMetrics.NetworkTxBytesPerSec
+	} else {
+		status.CheckErrors = append(status.CheckErrors, "node_exporter metrics failed: "+err.Error())
+	}
+
+	// Check configured services for real: systemd unit + local TCP port
+	checkSwitchGateServices(ctx, status, server, sgClient)
+	return nil
+}
+
+// checkSwitchGateServices checks services on a switch-gate server over SSH
+func checkSwitchGateServices(ctx context.Context, status *ServerStatus, server *config.ServerConfig, sgClient *switchgate.Client) {
+	if len(server.Services) == 0 {
+		return
+	}
+
+	checks := make([]switchgate.ServiceCheck, 0, len(server.Services))
+	for _, svc := range server.Services {
+		checks = append(checks, switchgate.ServiceCheck{
+			Unit: svc.Unit,
+			Port: svc.Port,
+		})
+	}
+
+	results, err := sgClient.CheckServices(ctx, checks)
+
+	for i, svc := range server.Services {
+		svcStatus := ServiceStatus{
+			Name: svc.Name,
+			Port: svc.Port,
+		}
+
+		if err != nil {
+			svcStatus.IsUp = false
+			svcStatus.Error = err.Error()
+		} else {
+			svcStatus.IsUp = results[i].IsUp()
+			svcStatus.Error = results[i].Error()
+		}
+
+		status.Services = append(status.Services, svcStatus)
+	}
+}
+
+// SSHCommandProbe runs a command over SSH (switch-gate upstreams only) and
+// reports it as a service: up if it exits 0 and the output contains Expect
+type SSHCommandProbe struct {
+	Config  *config.Config
+	Clients map[string]*switchgate.Client // key is upstream name
+}
+
+// Run implements Probe
+func (p *SSHCommandProbe) Run(ctx context.Context, target ProbeTarget, status *ServerStatus) error {
+	spec := target.Spec
+	svc := probeService(spec, "ssh: "+truncateCommand(spec.Command))
+
+	sgClient, ok := p.Clients[p.Config.GetUpstreamByIP(target.Server.IP)]
+	switch {
+	case spec.Command == "":
+		svc.Error = "no command configured"
+	case !ok:
+		svc.Error = fmt.Sprintf("no SSH access to %s", target.Server.IP)
+	default:
+		output, err := sgClient.Run(ctx, spec.Command)
+		switch {
+		case err != nil:
+			svc.Error = err.Error()
+		case spec.Expect != "" && !strings.Contains(output, spec.Expect):
+			svc.Error = fmt.Sprintf("output does not contain %q", spec.Expect)
+		default:
+			svc.IsUp = true
+		}
+	}
+
+	status.Services = append(status.Services, svc)
+	return nil
+}
+
+// truncateCommand shortens a command for use as a display name
+func truncateCommand(cmd string) string {
+	if runes := []rune(cmd); len(runes) > 30 {
+		return string(runes[:30]) + "..."
+	}
+	return cmd
+}
+
+// HTTPProbe requests a URL from the bot and reports it as a service
+// The URL accepts the same assertions as external checks
+type HTTPProbe struct {
+	Client *http.Client
+}
+
+// Run implements Probe
+func (p *HTTPProbe) Run(ctx context.Context, target ProbeTarget, status *ServerStatus) error {
+	spec := target.Spec
+	result := ExternalCheckResult{Target: spec.URL}
+	if strings.HasPrefix(spec.URL, "http://") || strings.HasPrefix(spec.URL, "https://") {
+		result = runCheck(ctx, p.Client, spec.URL)
+	} else {
+		result.Error = fmt.Sprintf("invalid URL %q (http:// or https:// expected)", spec.URL)
+	}
+
+	svc := probeService(spec, "HTTP "+result.Label())
+	svc.IsUp = result.OK
+	svc.Error = result.Error
+	status.Services = append(status.Services, svc)
+	return nil
+}
+
+// TCPProbe connects to an address from the bot and reports it as a service
+type TCPProbe struct{}
+
+// Run implements Probe
+func (p *TCPProbe) Run(ctx context.Context, target ProbeTarget, status *ServerStatus) error {
+	spec := target.Spec
+	svc := probeService(spec, "TCP "+spec.Address)
+
+	if spec.Address == "" {
+		svc.Error = "no address configured"
+	} else if err := checkTCP(ctx, spec.Address); err != nil {
+		svc.Error = err.Error()
+	} else {
+		svc.IsUp = true
+	}
+
+	status.Services = append(status.Services, svc)
+	return nil
+}
+
+// probeService returns a service status for a probe with its name and criticality
+func probeService(spec config.ProbeConfig, defaultName string) ServiceStatus {
+	name := spec.Name
+	if name == "" {
+		name = defaultName
+	}
+	criticality := spec.Criticality
+	if criticality == "" {
+		criticality = config.CriticalityWarning
+	}
+	return ServiceStatus{Name: name, Criticality: criticality}
+}
@@ -0,0 +1,298 @@
+// Package incident records the history of server and service incidents
+package incident
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/health"
+	"github.com/scinfra-pro/scinfra-bot/internal/store"
+)
+
+// Store logs (one JSON object per line, later lines win for the same ID)
+const (
+	incidentsLog = "incidents.jsonl"
+	eventsLog    = "events.jsonl"
+)
+
+// eventLookback attaches webhook events received shortly before an incident opened
+const eventLookback = 15 * time.Minute
+
+// Event is a webhook event related to a server
+type Event struct {
+	At       time.Time `json:"at"`
+	Source   string    `json:"source"` // webhook source (upstream name)
+	Name     string    `json:"name"`   // event name, e.g. "mode.changed"
+	Text     string    `json:"text,omitempty"`
+	ServerID string    `json:"server_id,omitempty"`
+}
+
+// Incident is a period during which a server or service was not up
+type Incident struct {
+	ID       int       `json:"id"`
+	ServerID string    `json:"server_id"`
+	Service  string    `json:"service,omitempty"` // empty for the server itself
+	Name     string    `json:"name"`
+	Level    string    `json:"level"` // worst level during the incident
+	Reasons  []string  `json:"reasons,omitempty"`
+	OpenedAt time.Time `json:"opened_at"`
+	ClosedAt time.Time `json:"closed_at"` // zero while ongoing
+	Events   []Event   `json:"events,omitempty"`
+}
+
+// Ongoing reports whether the incident is still open
+func (i *Incident) Ongoing() bool {
+	return i.ClosedAt.IsZero()
+}
+
+// Duration returns how long the incident lasted (until now if ongoing)
+func (i *Incident) Duration(now time.Time) time.Duration {
+	if i.Ongoing() {
+		return now.Sub(i.OpenedAt)
+	}
+	return i.ClosedAt.Sub(i.OpenedAt)
+}
+
+// key returns the target key of the incident
+func (i *Incident) key() string {
+	return targetKey(i.ServerID, i.Service)
+}
+
+// targetKey returns "serverID" or "serverID/service"
+func targetKey(serverID, service string) string {
+	if service == "" {
+		return serverID
+	}
+	return serverID + "/" + service
+}
+
+// Recorder opens and closes incidents from health transitions and keeps
+// them in the store
+type Recorder struct {
+	store     *store.Store // nil keeps history in memory only
+	retention time.Duration
+
+	mu        sync.Mutex
+	nextID    int
+	incidents []*Incident          // ordered by ID
+	open      map[string]*Incident // key: serverID or serverID/service
+	recent    []Event              // events within eventLookback
+}
+
+// NewRecorder creates a recorder, loading history from st
+// Incidents closed longer than retention ago are dropped
+func NewRecorder(st *store.Store, retention time.Duration) *Recorder {
+	r := &Recorder{
+		store:     st,
+		retention: retention,
+		nextID:    1,
+		open:      make(map[string]*Incident),
+	}
+	if st == nil {
+		return r
+	}
+
+	byID := make(map[int]*Incident)
+	err := st.Scan(incidentsLog, func(line []byte) error {
+		var inc Incident
+		if err := json.Unmarshal(line, &inc); err != nil {
+			return err
+		}
+		byID[inc.ID] = &inc
+		return nil
+	})
+	if err != nil {
+		log.Printf("Warning: failed to load incidents: %v", err)
+	}
+
+	now := time.Now()
+	for _, inc := range byID {
+		if inc.ID >= r.nextID {
+			r.nextID = inc.ID + 1
+		}
+		if !inc.Ongoing() && now.Sub(inc.ClosedAt) > retention {
+			continue
+		}
+		r.incidents = append(r.incidents, inc)
+		if inc.Ongoing() {
+			r.open[inc.key()] = inc
+		}
+	}
+	sort.Slice(r.incidents, func(i, j int) bool { return r.incidents[i].ID < r.incidents[j].ID })
+	log.Printf("Loaded %d incidents (%d ongoing)", len(r.incidents), len(r.open))
+
+	r.compact()
+	return r
+}
+
+// compact rewrites the incidents log with one line per retained incident
+func (r *Recorder) compact() {
+	values := make([]any, 0, len(r.incidents))
+	for _, inc := range r.incidents {
+		values = append(values, inc)
+	}
+	if err := r.store.Rewrite(incidentsLog, values); err != nil {
+		log.Printf("Warning: failed to compact incidents: %v", err)
+	}
+
+	cutoff := time.Now().Add(-r.retention)
+	var events []any
+	err := r.store.Scan(eventsLog, func(line []byte) error {
+		var ev Event
+		if err := json.Unmarshal(line, &ev); err != nil {
+			return err
+		}
+		if ev.At.After(cutoff) {
+			events = append(events, ev)
+		}
+		return nil
+	})
+	if err == nil {
+		err = r.store.Rewrite(eventsLog, events)
+	}
+	if err != nil {
+		log.Printf("Warning: failed to compact events: %v", err)
+	}
+}
+
+// RecordTransition implements health.TransitionRecorder
+// A level other than up opens an incident (or updates the open one),
+// up closes it
+func (r *Recorder) RecordTransition(t health.Transition) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := targetKey(t.ServerID, t.Service)
+	inc, isOpen := r.open[key]
+
+	if t.To == health.StatusUp {
+		if !isOpen {
+			return
+		}
+		inc.ClosedAt = t.Since
+		delete(r.open, key)
+		log.Printf("Incident #%d closed: %s", inc.ID, inc.Name)
+		r.saveLocked(inc)
+		return
+	}
+
+	changed := !isOpen
+	if !isOpen {
+		inc = &Incident{
+			ID:       r.nextID,
+			ServerID: t.ServerID,
+			Service:  t.Service,
+			Name:     t.Name,
+			Level:    string(t.To),
+			OpenedAt: t.Since,
+		}
+		r.nextID++
+		for _, ev := range r.recent {
+			if ev.ServerID == t.ServerID && t.Since.Sub(ev.At) <= eventLookback {
+				inc.Events = append(inc.Events, ev)
+			}
+		}
+		r.incidents = append(r.incidents, inc)
+		r.open[key] = inc
+		log.Printf("Incident #%d opened: %s %s", inc.ID, inc.Name, t.To)
+	} else if t.To == health.StatusDown && inc.Level != string(health.StatusDown) {
+		inc.Level = string(health.StatusDown)
+		changed = true
+	}
+
+	for _, reason := range t.Reasons {
+		if !containsString(inc.Reasons, reason) {
+			inc.Reasons = append(inc.Reasons, reason)
+			changed = true
+		}
+	}
+	if changed {
+		r.saveLocked(inc)
+	}
+}
+
+// RecordEvent logs a webhook event and attaches it to open incidents of its server
+func (r *Recorder) RecordEvent(ev Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.store != nil {
+		if err := r.store.Append(eventsLog, ev); err != nil {
+			log.Printf("Warning: failed to save event: %v", err)
+		}
+	}
+	if ev.ServerID == "" {
+		return
+	}
+
+	kept := r.recent[:0]
+	for _, e := range r.recent {
+		if ev.At.Sub(e.At) <= eventLookback {
+			kept = append(kept, e)
+		}
+	}
+	r.recent = append(kept, ev)
+
+	for _, inc := range r.open {
+		if inc.ServerID == ev.ServerID {
+			inc.Events = append(inc.Events, ev)
+			r.saveLocked(inc)
+		}
+	}
+}
+
+// List returns incidents of a server (all servers if empty) opened or still
+// ongoing since a time, newest first
+func (r *Recorder) List(serverID string, since time.Time) []Incident {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var list []Incident
+	for i := len(r.incidents) - 1; i >= 0; i-- {
+		inc := r.incidents[i]
+		if serverID != "" && inc.ServerID != serverID {
+			continue
+		}
+		if inc.OpenedAt.Before(since) && !inc.Ongoing() && inc.ClosedAt.Before(since) {
+			continue
+		}
+		list = append(list, *inc)
+	}
+	return list
+}
+
+// Get returns an incident by ID
+func (r *Recorder) Get(id int) (Incident, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, inc := range r.incidents {
+		if inc.ID == id {
+			return *inc, true
+		}
+	}
+	return Incident{}, false
+}
+
+// saveLocked appends the current snapshot of an incident to the log (errors are logged)
+func (r *Recorder) saveLocked(inc *Incident) {
+	if r.store == nil {
+		return
+	}
+	if err := r.store.Append(incidentsLog, inc); err != nil {
+		log.Printf("Warning: failed to save incident #%d: %v", inc.ID, err)
+	}
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
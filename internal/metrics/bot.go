@@ -0,0 +1,13 @@
+package metrics
+
+// Bot counters, incremented by the Telegram bot and webhook server
+var (
+	CommandsTotal = Default.NewCounterVec("scinfra_bot_commands_total",
+		"Telegram commands received by name", "command")
+	CallbacksTotal = Default.NewCounterVec("scinfra_bot_callbacks_total",
+		"Inline button presses by callback category", "category")
+	WebhookEventsTotal = Default.NewCounterVec("scinfra_bot_webhook_events_total",
+		"Webhook events received by route and event type", "route", "event")
+	TelegramErrorsTotal = Default.NewCounterVec("scinfra_bot_telegram_send_errors_total",
+		"Failed Telegram API requests by operation", "operation")
+)
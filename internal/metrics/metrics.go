@@ -0,0 +1,213 @@
+// Package metrics exposes bot metrics in the Prometheus text exposition format
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric types
+const (
+	TypeCounter = "counter"
+	TypeGauge   = "gauge"
+)
+
+// Sample is one labelled value of a metric family
+type Sample struct {
+	Labels []string // name/value pairs
+	Value  float64
+}
+
+// Family is a metric with its help text, type and samples
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Collector adds metric families computed at scrape time
+type Collector func(add func(Family))
+
+// Registry holds counters and collectors
+type Registry struct {
+	mu         sync.Mutex
+	counters   []*CounterVec
+	collectors []Collector
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Default is the registry served by Handler
+var Default = NewRegistry()
+
+// NewCounterVec creates and registers a counter with label names
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{name: name, help: help, labels: labels, values: make(map[string]*counterValue)}
+	r.mu.Lock()
+	r.counters = append(r.counters, c)
+	r.mu.Unlock()
+	return c
+}
+
+// RegisterCollector adds a collector called on every scrape
+func (r *Registry) RegisterCollector(c Collector) {
+	r.mu.Lock()
+	r.collectors = append(r.collectors, c)
+	r.mu.Unlock()
+}
+
+// Gather returns all metric families sorted by name
+func (r *Registry) Gather() []Family {
+	r.mu.Lock()
+	counters := append([]*CounterVec(nil), r.counters...)
+	collectors := append([]Collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	var families []Family
+	for _, c := range counters {
+		families = append(families, c.family())
+	}
+	for _, collect := range collectors {
+		collect(func(f Family) { families = append(families, f) })
+	}
+
+	sort.SliceStable(families, func(i, j int) bool { return families[i].Name < families[j].Name })
+	return families
+}
+
+// WriteText writes all metrics in the text exposition format
+func (r *Registry) WriteText(w io.Writer) error {
+	var sb strings.Builder
+	for _, f := range r.Gather() {
+		if len(f.Samples) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("# HELP %s %s\n", f.Name, escapeHelp(f.Help)))
+		sb.WriteString(fmt.Sprintf("# TYPE %s %s\n", f.Name, f.Type))
+		for _, s := range f.Samples {
+			sb.WriteString(f.Name)
+			sb.WriteString(formatLabels(s.Labels))
+			sb.WriteByte(' ')
+			sb.WriteString(formatValue(s.Value))
+			sb.WriteByte('\n')
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// Handler serves the registry over HTTP
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := r.WriteText(w); err != nil {
+			log.Printf("WARN: Failed to write metrics: %v", err)
+		}
+	})
+}
+
+// CounterVec is a monotonically increasing counter partitioned by labels
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]*counterValue // key: label values joined by \xff
+}
+
+// counterValue is the value of one label combination
+type counterValue struct {
+	labelValues []string
+	value       float64
+}
+
+// Inc increments the counter for the label values by 1
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increments the counter for the label values by v (ignored if negative)
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 || len(labelValues) != len(c.labels) {
+		return
+	}
+	key := strings.Join(labelValues, "\xff")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cv, ok := c.values[key]
+	if !ok {
+		cv = &counterValue{labelValues: append([]string(nil), labelValues...)}
+		c.values[key] = cv
+	}
+	cv.value += v
+}
+
+// family returns the counter as a metric family
+func (c *CounterVec) family() Family {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f := Family{Name: c.name, Help: c.help, Type: TypeCounter}
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		cv := c.values[key]
+		labels := make([]string, 0, 2*len(c.labels))
+		for i, name := range c.labels {
+			labels = append(labels, name, cv.labelValues[i])
+		}
+		f.Samples = append(f.Samples, Sample{Labels: labels, Value: cv.value})
+	}
+	return f
+}
+
+// formatLabels formats name/value pairs as {a="1",b="2"}
+func formatLabels(pairs []string) string {
+	if len(pairs) < 2 {
+		return ""
+	}
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, fmt.Sprintf("%s=\"%s\"", pairs[i], escapeLabel(pairs[i+1])))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// formatValue formats a sample value
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// escapeLabel escapes a label value
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// escapeHelp escapes help text
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
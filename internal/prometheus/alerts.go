@@ -0,0 +1,38 @@
+package prometheus
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// Alert states
+const (
+	AlertFiring  = "firing"
+	AlertPending = "pending"
+)
+
+// Alert is an active alert of a Prometheus alerting rule
+type Alert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	State       string            `json:"state"`    // "firing" or "pending"
+	ActiveAt    time.Time         `json:"activeAt"` // when the rule condition became true
+	Value       string            `json:"value"`    // sample value that triggered the alert
+}
+
+// Name returns the alertname label
+func (a Alert) Name() string {
+	return a.Labels["alertname"]
+}
+
+// Alerts returns the firing and pending alerts
+func (c *Client) Alerts() ([]Alert, error) {
+	var data struct {
+		Alerts []Alert `json:"alerts"`
+	}
+	if err := c.get(context.Background(), "/api/v1/alerts", url.Values{}, &data); err != nil {
+		return nil, err
+	}
+	return data.Alerts, nil
+}
@@ -0,0 +1,50 @@
+package prometheus
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Label matcher operators
+const (
+	MatchEqual     = "="
+	MatchNotEqual  = "!="
+	MatchRegexp    = "=~"
+	MatchNotRegexp = "!~"
+)
+
+// Matcher returns a label matcher with the value quoted and escaped,
+// e.g. Matcher("instance", MatchEqual, `a"b`) is instance="a\"b"
+// For regex operators the value is used as a regex; see EscapeRegex
+func Matcher(label, op, value string) string {
+	return label + op + QuoteLabelValue(value)
+}
+
+// QuoteLabelValue returns value as a double-quoted PromQL string
+// Backslashes, quotes and control characters are escaped
+func QuoteLabelValue(value string) string {
+	return strconv.Quote(value)
+}
+
+// EscapeRegex escapes regex metacharacters so value matches literally
+// Used for parts of =~ and !~ matchers, e.g. EscapeRegex("10.0.1.1") is 10\.0\.1\.1
+func EscapeRegex(value string) string {
+	return regexp.QuoteMeta(value)
+}
+
+// InstanceMatcher matches the instance label of a configured instance: the
+// exact value or the same host with a port ("10.0.1.11" matches "10.0.1.11:9100")
+// An empty instance matches all instances
+func InstanceMatcher(instance string) string {
+	if instance == "" {
+		return Matcher("instance", MatchNotEqual, "")
+	}
+	return Matcher("instance", MatchRegexp, EscapeRegex(instance)+`(:[0-9]+)?`)
+}
+
+// Selector returns a vector selector, e.g. Selector("up", Matcher("job", MatchEqual, "node"))
+// is up{job="node"}
+func Selector(metric string, matchers ...string) string {
+	return metric + "{" + strings.Join(matchers, ",") + "}"
+}
@@ -0,0 +1,97 @@
+package prometheus
+
+import (
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestMatcher(t *testing.T) {
+	tests := []struct {
+		label, op, value string
+		want             string
+	}{
+		{"job", MatchEqual, "node", `job="node"`},
+		{"job", MatchNotEqual, "", `job!=""`},
+		{"name", MatchEqual, `a"b`, `name="a\"b"`},
+		{"path", MatchEqual, `C:\tmp`, `path="C:\\tmp"`},
+		{"msg", MatchEqual, "a\nb", `msg="a\nb"`},
+		{"device", MatchNotRegexp, "lo|veth.*", `device!~"lo|veth.*"`},
+	}
+	for _, tt := range tests {
+		if got := Matcher(tt.label, tt.op, tt.value); got != tt.want {
+			t.Errorf("Matcher(%q, %q, %q) = %s, want %s", tt.label, tt.op, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSelector(t *testing.T) {
+	tests := []struct {
+		metric   string
+		matchers []string
+		want     string
+	}{
+		{"up", nil, "up{}"},
+		{"up", []string{Matcher("job", MatchEqual, "node")}, `up{job="node"}`},
+		{"node_load1", []string{Matcher("job", MatchEqual, "node"), Matcher("env", MatchNotEqual, "dev")}, `node_load1{job="node",env!="dev"}`},
+	}
+	for _, tt := range tests {
+		if got := Selector(tt.metric, tt.matchers...); got != tt.want {
+			t.Errorf("Selector(%q, %q) = %s, want %s", tt.metric, tt.matchers, got, tt.want)
+		}
+	}
+}
+
+func TestInstanceMatcher(t *testing.T) {
+	tests := []struct {
+		instance string
+		want     string
+		matches  []string
+		rejects  []string
+	}{
+		{
+			instance: "",
+			want:     `instance!=""`,
+		},
+		{
+			instance: "10.0.1.11",
+			want:     `instance=~"10\\.0\\.1\\.11(:[0-9]+)?"`,
+			matches:  []string{"10.0.1.11", "10.0.1.11:9100"},
+			rejects:  []string{"10.0.1.111", "10x0x1x11", "10.0.1.11:abc", "10.0.1.1"},
+		},
+		{
+			instance: "web.example.com:9100",
+			want:     `instance=~"web\\.example\\.com:9100(:[0-9]+)?"`,
+			matches:  []string{"web.example.com:9100"},
+			rejects:  []string{"web.example.com", "webxexample.com:9100"},
+		},
+	}
+	for _, tt := range tests {
+		got := InstanceMatcher(tt.instance)
+		if got != tt.want {
+			t.Errorf("InstanceMatcher(%q) = %s, want %s", tt.instance, got, tt.want)
+			continue
+		}
+		if len(tt.matches) == 0 && len(tt.rejects) == 0 {
+			continue
+		}
+
+		// Prometheus anchors regex matchers on both ends
+		quoted := got[len(`instance=~`):]
+		pattern, err := strconv.Unquote(quoted)
+		if err != nil {
+			t.Fatalf("InstanceMatcher(%q): unquote %s: %v", tt.instance, quoted, err)
+		}
+		re := regexp.MustCompile("^(?:" + pattern + ")$")
+		for _, s := range tt.matches {
+			if !re.MatchString(s) {
+				t.Errorf("InstanceMatcher(%q) does not match %q", tt.instance, s)
+			}
+		}
+		for _, s := range tt.rejects {
+			if re.MatchString(s) {
+				t.Errorf("InstanceMatcher(%q) matches %q", tt.instance, s)
+			}
+		}
+	}
+}
@@ -1,11 +1,18 @@
 package prometheus
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"net/url"
+	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,24 +22,56 @@ type Client struct {
 	httpClient *http.Client
 }
 
-// QueryResult represents a single metric result
+// QueryResult represents a single metric result with its full label set
 type QueryResult struct {
-	Instance string
-	Value    float64
+	Metric map[string]string
+	Value  float64
+}
+
+// Instance returns the instance label of the result
+func (r QueryResult) Instance() string {
+	return r.Metric["instance"]
+}
+
+// Point is one sample of a range query result
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series is one time series of a range query result (matrix)
+type Series struct {
+	Metric map[string]string
+	Points []Point
 }
 
 // prometheusResponse represents the Prometheus API response
 type prometheusResponse struct {
-	Status string `json:"status"`
-	Data   struct {
-		ResultType string `json:"resultType"`
-		Result     []struct {
-			Metric map[string]string `json:"metric"`
-			Value  []interface{}     `json:"value"` // [timestamp, "value"]
-		} `json:"result"`
-	} `json:"data"`
-	Error     string `json:"error,omitempty"`
-	ErrorType string `json:"errorType,omitempty"`
+	Status    string          `json:"status"`
+	Data      json.RawMessage `json:"data"` // layout depends on the endpoint
+	Error     string          `json:"error,omitempty"`
+	ErrorType string          `json:"errorType,omitempty"`
+}
+
+// queryData is the data of a query response
+type queryData struct {
+	ResultType ValueType       `json:"resultType"`
+	Result     json.RawMessage `json:"result"` // layout depends on ResultType
+}
+
+// Options configures authentication, TLS and headers of a client
+type Options struct {
+	Username    string // Basic auth
+	Password    string
+	BearerToken string
+
+	CAFile             string // PEM bundle to verify the server certificate
+	CertFile           string // Client certificate (PEM)
+	KeyFile            string // Client key (PEM)
+	InsecureSkipVerify bool
+
+	Headers map[string]string // Extra request headers
+	Timeout time.Duration     // Request timeout (default 10s)
 }
 
 // NewClient creates a new Prometheus client
@@ -45,178 +84,229 @@ func NewClient(baseURL string) *Client {
 	}
 }
 
-// Query executes a PromQL query and returns results
-func (c *Client) Query(promql string) ([]QueryResult, error) {
-	endpoint := fmt.Sprintf("%s/api/v1/query", c.baseURL)
-
-	params := url.Values{}
-	params.Set("query", promql)
+// NewClientWithOptions creates a Prometheus client with authentication and TLS settings
+// Returns an error if the CA bundle or client certificate cannot be loaded
+func NewClientWithOptions(baseURL string, opts Options) (*Client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify}
 
-	resp, err := c.httpClient.Get(endpoint + "?" + params.Encode())
-	if err != nil {
-		return nil, fmt.Errorf("prometheus query failed: %w", err)
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.CAFile)
+		}
+		tlsConfig.RootCAs = pool
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("prometheus returned status %d", resp.StatusCode)
+	if opts.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
 	}
 
-	var promResp prometheusResponse
-	if err := json.NewDecoder(resp.Body).Decode(&promResp); err != nil {
-		return nil, fmt.Errorf("failed to decode prometheus response: %w", err)
-	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
 
-	if promResp.Status != "success" {
-		return nil, fmt.Errorf("prometheus error: %s - %s", promResp.ErrorType, promResp.Error)
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
 	}
 
-	var results []QueryResult
-	for _, r := range promResp.Data.Result {
-		if len(r.Value) < 2 {
-			continue
-		}
-
-		valueStr, ok := r.Value[1].(string)
-		if !ok {
-			continue
-		}
+	return &Client{
+		baseURL: baseURL,
+		httpClient: &http.Client{
+			Timeout:   timeout,
+			Transport: &authTransport{base: transport, opts: opts},
+		},
+	}, nil
+}
 
-		value, err := strconv.ParseFloat(valueStr, 64)
-		if err != nil {
-			continue
-		}
+// authTransport adds credentials and extra headers to every request
+type authTransport struct {
+	base http.RoundTripper
+	opts Options
+}
 
-		results = append(results, QueryResult{
-			Instance: r.Metric["instance"],
-			Value:    value,
-		})
+// RoundTrip implements http.RoundTripper
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.opts.Headers {
+		req.Header.Set(k, v)
+	}
+	switch {
+	case t.opts.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+t.opts.BearerToken)
+	case t.opts.Username != "":
+		req.SetBasicAuth(t.opts.Username, t.opts.Password)
 	}
+	return t.base.RoundTrip(req)
+}
 
-	return results, nil
+// Query executes a PromQL query and returns results
+// Scalar results are returned as a single result without labels
+func (c *Client) Query(promql string) ([]QueryResult, error) {
+	return c.QueryContext(context.Background(), promql)
 }
 
-// QuerySingle executes a query and returns the first result value
-func (c *Client) QuerySingle(promql string) (float64, error) {
-	results, err := c.Query(promql)
+// QueryContext is Query with a context for cancellation
+func (c *Client) QueryContext(ctx context.Context, promql string) ([]QueryResult, error) {
+	value, err := c.QueryValueContext(ctx, promql)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	if len(results) == 0 {
-		return 0, fmt.Errorf("no results for query: %s", promql)
+	switch value.Type {
+	case ValueVector:
+		return value.Vector, nil
+	case ValueScalar:
+		return []QueryResult{{Metric: map[string]string{}, Value: value.Scalar.Value}}, nil
+	default:
+		return nil, fmt.Errorf("unexpected prometheus result type %q (expected vector or scalar)", value.Type)
 	}
+}
 
-	return results[0].Value, nil
+// QueryValue executes an instant query and returns the result of any type
+func (c *Client) QueryValue(promql string) (*Value, error) {
+	return c.QueryValueContext(context.Background(), promql)
 }
 
-// IsUp checks if an instance is up (returns true if up == 1)
-// instance can be either hostname (e.g., "edge-gateway") or IP:port (e.g., "10.0.1.11:9100")
-func (c *Client) IsUp(instance string) (bool, error) {
-	// First try exact match (for hostname-based labels like "edge-gateway")
-	query := fmt.Sprintf(`up{instance="%s",job="node"}`, instance)
-	value, err := c.QuerySingle(query)
+// QueryValueContext is QueryValue with a context for cancellation
+func (c *Client) QueryValueContext(ctx context.Context, promql string) (*Value, error) {
+	params := url.Values{}
+	params.Set("query", promql)
+
+	var data queryData
+	if err := c.get(ctx, "/api/v1/query", params, &data); err != nil {
+		return nil, err
+	}
+	return decodeValue(data.ResultType, data.Result)
+}
+
+// QueryRange executes a PromQL range query and returns one series per result
+// Samples that are not numbers (e.g. NaN) are skipped
+func (c *Client) QueryRange(promql string, start, end time.Time, step time.Duration) ([]Series, error) {
+	params := url.Values{}
+	params.Set("query", promql)
+	params.Set("start", formatTimestamp(start))
+	params.Set("end", formatTimestamp(end))
+	params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+
+	var data queryData
+	if err := c.get(context.Background(), "/api/v1/query_range", params, &data); err != nil {
+		return nil, err
+	}
+	value, err := decodeValue(data.ResultType, data.Result)
 	if err != nil {
-		// Try with regex match
-		query = fmt.Sprintf(`up{instance=~"%s.*",job="node"}`, instance)
-		value, err = c.QuerySingle(query)
-		if err != nil {
-			return false, err
+		return nil, err
+	}
+	if value.Type != ValueMatrix {
+		return nil, fmt.Errorf("unexpected prometheus result type %q (expected matrix)", value.Type)
+	}
+
+	for i := range value.Matrix {
+		points := value.Matrix[i].Points[:0]
+		for _, p := range value.Matrix[i].Points {
+			if !math.IsNaN(p.Value) && !math.IsInf(p.Value, 0) {
+				points = append(points, p)
+			}
 		}
+		value.Matrix[i].Points = points
 	}
-	return value == 1, nil
-}
 
-// GetCPU returns CPU usage percentage for an instance
-func (c *Client) GetCPU(instance string) (float64, error) {
-	query := fmt.Sprintf(
-		`100 - avg(rate(node_cpu_seconds_total{mode="idle",instance="%s"}[5m]))*100`,
-		instance,
-	)
-	return c.QuerySingle(query)
+	return value.Matrix, nil
 }
 
-// GetMemory returns memory usage percentage for an instance
-func (c *Client) GetMemory(instance string) (float64, error) {
-	query := fmt.Sprintf(
-		`(1 - node_memory_MemAvailable_bytes{instance="%s"}/node_memory_MemTotal_bytes{instance="%s"})*100`,
-		instance, instance,
-	)
-	return c.QuerySingle(query)
-}
+// get calls a Prometheus API endpoint and decodes the data of a successful
+// response into data
+func (c *Client) get(ctx context.Context, path string, params url.Values, data any) error {
+	endpoint := c.baseURL + path
 
-// GetMemoryBytes returns memory usage in bytes (used, total)
-func (c *Client) GetMemoryBytes(instance string) (used, total float64, err error) {
-	totalQuery := fmt.Sprintf(`node_memory_MemTotal_bytes{instance="%s"}`, instance)
-	total, err = c.QuerySingle(totalQuery)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+params.Encode(), nil)
 	if err != nil {
-		return 0, 0, err
+		return fmt.Errorf("prometheus query failed: %w", err)
 	}
-
-	availQuery := fmt.Sprintf(`node_memory_MemAvailable_bytes{instance="%s"}`, instance)
-	avail, err := c.QuerySingle(availQuery)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return 0, 0, err
+		return fmt.Errorf("prometheus query failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var promResp prometheusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&promResp); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("prometheus returned status %d", resp.StatusCode)
+		}
+		return fmt.Errorf("failed to decode prometheus response: %w", err)
 	}
 
-	used = total - avail
-	return used, total, nil
+	if promResp.Status != "success" {
+		return fmt.Errorf("prometheus error: %s - %s", promResp.ErrorType, promResp.Error)
+	}
+
+	if err := json.Unmarshal(promResp.Data, data); err != nil {
+		return fmt.Errorf("failed to decode prometheus response: %w", err)
+	}
+	return nil
 }
 
-// GetDisk returns disk usage percentage for an instance (root filesystem)
-func (c *Client) GetDisk(instance string) (float64, error) {
-	query := fmt.Sprintf(
-		`(1 - node_filesystem_avail_bytes{instance="%s",mountpoint="/"}/node_filesystem_size_bytes{instance="%s",mountpoint="/"})*100`,
-		instance, instance,
-	)
-	return c.QuerySingle(query)
+// formatTimestamp formats t as Unix seconds for the Prometheus API
+func formatTimestamp(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixNano())/1e9, 'f', 3, 64)
 }
 
-// GetDiskBytes returns disk usage in bytes (used, total) for root filesystem
-func (c *Client) GetDiskBytes(instance string) (used, total float64, err error) {
-	totalQuery := fmt.Sprintf(`node_filesystem_size_bytes{instance="%s",mountpoint="/"}`, instance)
-	total, err = c.QuerySingle(totalQuery)
+// QuerySingle executes a query and returns the first result value
+func (c *Client) QuerySingle(promql string) (float64, error) {
+	results, err := c.Query(promql)
 	if err != nil {
-		return 0, 0, err
+		return 0, err
 	}
 
-	availQuery := fmt.Sprintf(`node_filesystem_avail_bytes{instance="%s",mountpoint="/"}`, instance)
-	avail, err := c.QuerySingle(availQuery)
-	if err != nil {
-		return 0, 0, err
+	if len(results) == 0 {
+		return 0, fmt.Errorf("no results for query: %s", promql)
 	}
 
-	used = total - avail
-	return used, total, nil
+	return results[0].Value, nil
 }
 
-// GetUptime returns the uptime of an instance
-func (c *Client) GetUptime(instance string) (time.Duration, error) {
-	query := fmt.Sprintf(
-		`node_time_seconds{instance="%s"} - node_boot_time_seconds{instance="%s"}`,
-		instance, instance,
-	)
-	seconds, err := c.QuerySingle(query)
-	if err != nil {
-		return 0, err
-	}
-	return time.Duration(seconds) * time.Second, nil
+// VirtualNetDevicePrefixes are name prefixes of loopback and virtual network
+// interfaces, excluded from network rates (PromQL queries and node_exporter text)
+var VirtualNetDevicePrefixes = []string{"lo", "veth", "docker", "br-", "virbr"}
+
+// PseudoFSTypeNames are pseudo filesystem types excluded from disk usage
+var PseudoFSTypeNames = []string{
+	"tmpfs", "devtmpfs", "ramfs", "overlay", "squashfs", "nsfs", "autofs", "fuse.lxcfs", "efivarfs",
 }
 
-// IsServiceUp checks if a specific service/job is up
-func (c *Client) IsServiceUp(job string, instance string) (bool, error) {
-	var query string
-	if instance != "" {
-		query = fmt.Sprintf(`up{job="%s",instance=~"%s.*"}`, job, instance)
-	} else {
-		query = fmt.Sprintf(`up{job="%s"}`, job)
-	}
+// VirtualNetDevices matches VirtualNetDevicePrefixes (PromQL regex; names are
+// not escaped, as a backslash would need double escaping in a PromQL string)
+var VirtualNetDevices = "(" + strings.Join(VirtualNetDevicePrefixes, "|") + ").*"
 
-	value, err := c.QuerySingle(query)
-	if err != nil {
-		return false, err
+// PseudoFSTypes matches PseudoFSTypeNames (PromQL regex)
+var PseudoFSTypes = strings.Join(PseudoFSTypeNames, "|")
+
+// IsVirtualNetDevice reports whether a network device is loopback or virtual
+func IsVirtualNetDevice(device string) bool {
+	for _, prefix := range VirtualNetDevicePrefixes {
+		if strings.HasPrefix(device, prefix) {
+			return true
+		}
 	}
-	return value == 1, nil
+	return false
+}
+
+// IsPseudoFS reports whether a filesystem type is a pseudo filesystem
+func IsPseudoFS(fstype string) bool {
+	return slices.Contains(PseudoFSTypeNames, fstype)
+}
+
+// Timeout returns the request timeout of the client
+func (c *Client) Timeout() time.Duration {
+	return c.httpClient.Timeout
 }
 
 // Ping checks if Prometheus is reachable
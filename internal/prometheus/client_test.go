@@ -0,0 +1,43 @@
+package prometheus
+
+import "testing"
+
+func TestIsVirtualNetDevice(t *testing.T) {
+	tests := []struct {
+		device string
+		want   bool
+	}{
+		{"lo", true},
+		{"veth1a2b3c", true},
+		{"docker0", true},
+		{"br-0123456789ab", true},
+		{"virbr0", true},
+		{"eth0", false},
+		{"ens3", false},
+		{"wg0", false},
+	}
+	for _, tt := range tests {
+		if got := IsVirtualNetDevice(tt.device); got != tt.want {
+			t.Errorf("IsVirtualNetDevice(%q) = %v, want %v", tt.device, got, tt.want)
+		}
+	}
+}
+
+func TestIsPseudoFS(t *testing.T) {
+	tests := []struct {
+		fstype string
+		want   bool
+	}{
+		{"tmpfs", true},
+		{"overlay", true},
+		{"fuse.lxcfs", true},
+		{"ext4", false},
+		{"xfs", false},
+		{"fuse", false},
+	}
+	for _, tt := range tests {
+		if got := IsPseudoFS(tt.fstype); got != tt.want {
+			t.Errorf("IsPseudoFS(%q) = %v, want %v", tt.fstype, got, tt.want)
+		}
+	}
+}
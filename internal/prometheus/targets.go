@@ -0,0 +1,50 @@
+package prometheus
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// Target health values
+const (
+	TargetUp      = "up"
+	TargetDown    = "down"
+	TargetUnknown = "unknown"
+)
+
+// Target is an active scrape target
+type Target struct {
+	Labels             map[string]string `json:"labels"`           // after relabeling (job, instance, ...)
+	DiscoveredLabels   map[string]string `json:"discoveredLabels"` // before relabeling
+	ScrapePool         string            `json:"scrapePool"`
+	ScrapeURL          string            `json:"scrapeUrl"`
+	Health             string            `json:"health"` // "up", "down" or "unknown"
+	LastError          string            `json:"lastError"`
+	LastScrape         time.Time         `json:"lastScrape"`
+	LastScrapeDuration float64           `json:"lastScrapeDuration"` // seconds
+}
+
+// Instance returns the instance label of the target
+func (t Target) Instance() string {
+	return t.Labels["instance"]
+}
+
+// Job returns the job label of the target
+func (t Target) Job() string {
+	return t.Labels["job"]
+}
+
+// Targets returns the active scrape targets
+func (c *Client) Targets() ([]Target, error) {
+	params := url.Values{}
+	params.Set("state", "active")
+
+	var data struct {
+		ActiveTargets []Target `json:"activeTargets"`
+	}
+	if err := c.get(context.Background(), "/api/v1/targets", params, &data); err != nil {
+		return nil, err
+	}
+	return data.ActiveTargets, nil
+}
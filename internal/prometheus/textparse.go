@@ -0,0 +1,143 @@
+package prometheus
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Sample represents a single sample from the Prometheus text exposition format
+type Sample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+// ParseText parses metrics in the Prometheus text exposition format
+// (as served by node_exporter on /metrics). Comments, HELP and TYPE lines
+// are skipped; timestamps are ignored.
+func ParseText(r io.Reader) ([]Sample, error) {
+	var samples []Sample
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		sample, err := parseSampleLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		samples = append(samples, sample)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read metrics: %w", err)
+	}
+
+	return samples, nil
+}
+
+// parseSampleLine parses `metric_name{label="value",...} value [timestamp]`
+func parseSampleLine(line string) (Sample, error) {
+	sample := Sample{Labels: make(map[string]string)}
+
+	// Metric name ends at '{' or whitespace
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return sample, fmt.Errorf("missing metric name or value")
+	}
+	sample.Name = line[:end]
+	rest := line[end:]
+
+	// Optional label set
+	if strings.HasPrefix(rest, "{") {
+		n, err := parseLabels(rest, sample.Labels)
+		if err != nil {
+			return sample, fmt.Errorf("metric %s: %w", sample.Name, err)
+		}
+		rest = rest[n:]
+	}
+
+	// Value and optional timestamp
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return sample, fmt.Errorf("metric %s: expected value [timestamp]", sample.Name)
+	}
+
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return sample, fmt.Errorf("metric %s: invalid value %q", sample.Name, fields[0])
+	}
+	sample.Value = value
+
+	return sample, nil
+}
+
+// parseLabels parses a `{name="value",...}` block into labels
+// Returns the number of bytes consumed including the closing brace
+func parseLabels(s string, labels map[string]string) (int, error) {
+	i := 1 // skip '{'
+	for {
+		// Skip whitespace and separators
+		for i < len(s) && (s[i] == ' ' || s[i] == '\t' || s[i] == ',') {
+			i++
+		}
+		if i >= len(s) {
+			return 0, fmt.Errorf("unterminated label set")
+		}
+		if s[i] == '}' {
+			return i + 1, nil
+		}
+
+		// Label name
+		eq := strings.IndexByte(s[i:], '=')
+		if eq <= 0 {
+			return 0, fmt.Errorf("invalid label at offset %d", i)
+		}
+		name := strings.TrimSpace(s[i : i+eq])
+		i += eq + 1
+
+		// Quoted label value with escapes: \\, \" and \n
+		if i >= len(s) || s[i] != '"' {
+			return 0, fmt.Errorf("label %s: value must be quoted", name)
+		}
+		i++
+
+		var value strings.Builder
+		closed := false
+		for i < len(s) {
+			ch := s[i]
+			if ch == '\\' && i+1 < len(s) {
+				switch s[i+1] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i+1])
+				}
+				i += 2
+				continue
+			}
+			if ch == '"' {
+				closed = true
+				i++
+				break
+			}
+			value.WriteByte(ch)
+			i++
+		}
+		if !closed {
+			return 0, fmt.Errorf("label %s: unterminated value", name)
+		}
+
+		labels[name] = value.String()
+	}
+}
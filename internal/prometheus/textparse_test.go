@@ -0,0 +1,130 @@
+package prometheus
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseText(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []Sample
+		wantErr string
+	}{
+		{
+			name: "comments and blank lines",
+			input: `# HELP node_load1 1m load average.
+# TYPE node_load1 gauge
+
+node_load1 0.42
+`,
+			want: []Sample{{Name: "node_load1", Labels: map[string]string{}, Value: 0.42}},
+		},
+		{
+			name:  "labels",
+			input: `node_filesystem_avail_bytes{device="/dev/sda1",fstype="ext4",mountpoint="/"} 1.2e+10`,
+			want: []Sample{{
+				Name:   "node_filesystem_avail_bytes",
+				Labels: map[string]string{"device": "/dev/sda1", "fstype": "ext4", "mountpoint": "/"},
+				Value:  1.2e10,
+			}},
+		},
+		{
+			name:  "timestamp is ignored",
+			input: `node_cpu_seconds_total{cpu="0",mode="idle"} 12345.6 1712000000000`,
+			want: []Sample{{
+				Name:   "node_cpu_seconds_total",
+				Labels: map[string]string{"cpu": "0", "mode": "idle"},
+				Value:  12345.6,
+			}},
+		},
+		{
+			name:  "escaped label values",
+			input: `x{path="C:\\dir",quote="say \"hi\"",nl="a\nb"} 1`,
+			want: []Sample{{
+				Name:   "x",
+				Labels: map[string]string{"path": `C:\dir`, "quote": `say "hi"`, "nl": "a\nb"},
+				Value:  1,
+			}},
+		},
+		{
+			name:  "trailing comma and spaces in label set",
+			input: `x{ a="1", b="2", } 3`,
+			want:  []Sample{{Name: "x", Labels: map[string]string{"a": "1", "b": "2"}, Value: 3}},
+		},
+		{
+			name:  "empty label set",
+			input: `x{} 5`,
+			want:  []Sample{{Name: "x", Labels: map[string]string{}, Value: 5}},
+		},
+		{
+			name:  "special values",
+			input: "a +Inf\nb -Inf",
+			want: []Sample{
+				{Name: "a", Labels: map[string]string{}, Value: math.Inf(1)},
+				{Name: "b", Labels: map[string]string{}, Value: math.Inf(-1)},
+			},
+		},
+		{
+			name:    "missing value",
+			input:   "node_load1",
+			wantErr: "line 1: missing metric name or value",
+		},
+		{
+			name:    "invalid value",
+			input:   "ok 1\nnode_load1 abc",
+			wantErr: `line 2: metric node_load1: invalid value "abc"`,
+		},
+		{
+			name:    "too many fields",
+			input:   "x 1 2 3",
+			wantErr: "expected value [timestamp]",
+		},
+		{
+			name:    "unquoted label value",
+			input:   `x{a=1} 1`,
+			wantErr: "label a: value must be quoted",
+		},
+		{
+			name:    "unterminated label value",
+			input:   `x{a="1} 1`,
+			wantErr: "label a: unterminated value",
+		},
+		{
+			name:    "unterminated label set",
+			input:   `x{a="1"`,
+			wantErr: "unterminated label set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseText(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseText() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseText() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseText() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTextNaN(t *testing.T) {
+	got, err := ParseText(strings.NewReader("x NaN"))
+	if err != nil {
+		t.Fatalf("ParseText() error = %v", err)
+	}
+	if len(got) != 1 || !math.IsNaN(got[0].Value) {
+		t.Errorf("ParseText() = %+v, want one NaN sample", got)
+	}
+}
@@ -0,0 +1,136 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ValueType is the type of a query result
+type ValueType string
+
+const (
+	ValueVector ValueType = "vector" // one sample per series
+	ValueMatrix ValueType = "matrix" // a range of samples per series
+	ValueScalar ValueType = "scalar" // a single number
+	ValueString ValueType = "string" // a single string
+)
+
+// Value is a query result of any type; only the field of Type is set
+type Value struct {
+	Type   ValueType
+	Vector []QueryResult
+	Matrix []Series
+	Scalar Point
+	String string
+	Time   time.Time // evaluation time of scalar and string results
+}
+
+// decodeValue decodes the "result" field of a query response
+func decodeValue(resultType ValueType, raw json.RawMessage) (*Value, error) {
+	value := &Value{Type: resultType}
+
+	switch resultType {
+	case ValueVector:
+		var results []struct {
+			Metric map[string]string `json:"metric"`
+			Value  []interface{}     `json:"value"` // [timestamp, "value"]
+		}
+		if err := json.Unmarshal(raw, &results); err != nil {
+			return nil, fmt.Errorf("failed to decode vector: %w", err)
+		}
+		for _, r := range results {
+			_, v, err := parseSamplePair(r.Value)
+			if err != nil {
+				continue
+			}
+			value.Vector = append(value.Vector, QueryResult{Metric: r.Metric, Value: v})
+		}
+
+	case ValueMatrix:
+		var results []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][]interface{}   `json:"values"` // [[timestamp, "value"], ...]
+		}
+		if err := json.Unmarshal(raw, &results); err != nil {
+			return nil, fmt.Errorf("failed to decode matrix: %w", err)
+		}
+		for _, r := range results {
+			series := Series{Metric: r.Metric}
+			for _, pair := range r.Values {
+				t, v, err := parseSamplePair(pair)
+				if err != nil {
+					continue
+				}
+				series.Points = append(series.Points, Point{Time: t, Value: v})
+			}
+			value.Matrix = append(value.Matrix, series)
+		}
+
+	case ValueScalar:
+		var pair []interface{}
+		if err := json.Unmarshal(raw, &pair); err != nil {
+			return nil, fmt.Errorf("failed to decode scalar: %w", err)
+		}
+		t, v, err := parseSamplePair(pair)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode scalar: %w", err)
+		}
+		value.Scalar = Point{Time: t, Value: v}
+		value.Time = t
+
+	case ValueString:
+		var pair []interface{}
+		if err := json.Unmarshal(raw, &pair); err != nil {
+			return nil, fmt.Errorf("failed to decode string: %w", err)
+		}
+		t, err := parseTimestamp(pair)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode string: %w", err)
+		}
+		value.String, _ = pair[1].(string)
+		value.Time = t
+
+	default:
+		return nil, fmt.Errorf("unknown prometheus result type %q", resultType)
+	}
+
+	return value, nil
+}
+
+// parseSamplePair parses a [timestamp, "value"] pair
+func parseSamplePair(pair []interface{}) (time.Time, float64, error) {
+	t, err := parseTimestamp(pair)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	valueStr, ok := pair[1].(string)
+	if !ok {
+		return time.Time{}, 0, fmt.Errorf("sample value is not a string")
+	}
+
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("invalid sample value: %w", err)
+	}
+
+	return t, value, nil
+}
+
+// parseTimestamp parses the timestamp of a [timestamp, "value"] pair
+func parseTimestamp(pair []interface{}) (time.Time, error) {
+	if len(pair) < 2 {
+		return time.Time{}, fmt.Errorf("expected [timestamp, value], got %d elements", len(pair))
+	}
+
+	ts, ok := pair[0].(float64)
+	if !ok {
+		return time.Time{}, fmt.Errorf("timestamp is not a number")
+	}
+
+	sec, frac := math.Modf(ts)
+	return time.Unix(int64(sec), int64(frac*1e9)), nil
+}
@@ -0,0 +1,105 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeValue(t *testing.T) {
+	ts := time.Unix(1712000000, 250_000_000)
+
+	tests := []struct {
+		name       string
+		resultType ValueType
+		raw        string
+		want       *Value
+		wantErr    string
+	}{
+		{
+			name:       "vector",
+			resultType: ValueVector,
+			raw:        `[{"metric":{"instance":"a:9100"},"value":[1712000000.25,"1.5"]},{"metric":{},"value":[1712000000.25,"NaN-ish"]}]`,
+			want: &Value{Type: ValueVector, Vector: []QueryResult{
+				{Metric: map[string]string{"instance": "a:9100"}, Value: 1.5},
+			}},
+		},
+		{
+			name:       "empty vector",
+			resultType: ValueVector,
+			raw:        `[]`,
+			want:       &Value{Type: ValueVector},
+		},
+		{
+			name:       "matrix",
+			resultType: ValueMatrix,
+			raw:        `[{"metric":{"job":"node"},"values":[[1712000000.25,"1"],[1712000060.25,"2"],[1712000120.25,"bad"]]}]`,
+			want: &Value{Type: ValueMatrix, Matrix: []Series{{
+				Metric: map[string]string{"job": "node"},
+				Points: []Point{{Time: ts, Value: 1}, {Time: ts.Add(time.Minute), Value: 2}},
+			}}},
+		},
+		{
+			name:       "scalar",
+			resultType: ValueScalar,
+			raw:        `[1712000000.25,"42"]`,
+			want:       &Value{Type: ValueScalar, Scalar: Point{Time: ts, Value: 42}, Time: ts},
+		},
+		{
+			name:       "string",
+			resultType: ValueString,
+			raw:        `[1712000000.25,"hello"]`,
+			want:       &Value{Type: ValueString, String: "hello", Time: ts},
+		},
+		{
+			name:       "scalar with invalid value",
+			resultType: ValueScalar,
+			raw:        `[1712000000.25,"x"]`,
+			wantErr:    "failed to decode scalar: invalid sample value",
+		},
+		{
+			name:       "scalar with short pair",
+			resultType: ValueScalar,
+			raw:        `[1712000000.25]`,
+			wantErr:    "expected [timestamp, value], got 1 elements",
+		},
+		{
+			name:       "string with text timestamp",
+			resultType: ValueString,
+			raw:        `["now","hello"]`,
+			wantErr:    "timestamp is not a number",
+		},
+		{
+			name:       "malformed vector",
+			resultType: ValueVector,
+			raw:        `{}`,
+			wantErr:    "failed to decode vector",
+		},
+		{
+			name:       "unknown type",
+			resultType: "histogram",
+			raw:        `[]`,
+			wantErr:    `unknown prometheus result type "histogram"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeValue(tt.resultType, json.RawMessage(tt.raw))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("decodeValue() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeValue() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeValue() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
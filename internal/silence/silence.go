@@ -0,0 +1,318 @@
+// Package silence manages alert silences and maintenance windows
+package silence
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/config"
+	"github.com/scinfra-pro/scinfra-bot/internal/store"
+)
+
+// stateFile is the store document holding manual silences
+const stateFile = "silences.json"
+
+// Target kinds
+const (
+	KindServer  = "server"
+	KindService = "service"
+	KindCloud   = "cloud"
+)
+
+// Silence suppresses notifications for a server, service or cloud
+type Silence struct {
+	ID        int       `json:"id"`
+	Kind      string    `json:"kind"`   // server, service or cloud
+	Target    string    `json:"target"` // server ID, "server/service" or cloud name
+	Reason    string    `json:"reason,omitempty"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+
+	// Scheduled silences come from infrastructure.maintenance and cannot be changed
+	Scheduled bool `json:"-"`
+}
+
+// Remaining returns the time left until the silence ends
+func (s *Silence) Remaining(now time.Time) time.Duration {
+	return s.EndsAt.Sub(now)
+}
+
+// covers reports whether the silence applies to a server (service == "") or service
+func (s *Silence) covers(cloud, serverID, service string) bool {
+	switch s.Kind {
+	case KindCloud:
+		return s.Target == cloud
+	case KindServer:
+		return s.Target == serverID
+	case KindService:
+		return service != "" && s.Target == serverID+"/"+service
+	}
+	return false
+}
+
+// state is the persisted file content
+type state struct {
+	NextID   int        `json:"next_id"`
+	Silences []*Silence `json:"silences"`
+}
+
+// Manager keeps active silences and persists them in the store
+type Manager struct {
+	cfg   *config.Config
+	store *store.Store // nil keeps silences in memory only
+
+	mu       sync.Mutex
+	nextID   int
+	silences []*Silence
+}
+
+// NewManager creates a manager persisting to st (nil keeps silences in memory)
+// A missing or unreadable document is logged and starts with no silences
+func NewManager(cfg *config.Config, st *store.Store) *Manager {
+	m := &Manager{cfg: cfg, store: st, nextID: 1}
+	if st == nil {
+		return m
+	}
+
+	var saved state
+	if err := st.Load(stateFile, &saved); err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			log.Printf("Warning: failed to load silences: %v", err)
+		}
+		return m
+	}
+	if saved.NextID > m.nextID {
+		m.nextID = saved.NextID
+	}
+	now := time.Now()
+	for _, s := range saved.Silences {
+		if s.EndsAt.After(now) {
+			m.silences = append(m.silences, s)
+		}
+	}
+	log.Printf("Loaded %d active silences", len(m.silences))
+
+	return m
+}
+
+// Resolve parses a target: cloud name (spaces may be written as "_"),
+// server ID or name, or "server/service"
+func (m *Manager) Resolve(target string) (kind, resolved string, err error) {
+	infra := &m.cfg.Infrastructure
+
+	if serverPart, service, ok := strings.Cut(target, "/"); ok {
+		server := m.findServer(serverPart)
+		if server == nil {
+			return "", "", fmt.Errorf("unknown server %q", serverPart)
+		}
+		for _, svc := range server.Services {
+			if strings.EqualFold(svc.Name, service) {
+				return KindService, server.ID + "/" + svc.Name, nil
+			}
+		}
+		for _, probe := range server.Probes {
+			if probe.Name != "" && strings.EqualFold(probe.Name, service) {
+				return KindService, server.ID + "/" + probe.Name, nil
+			}
+		}
+		return "", "", fmt.Errorf("unknown service %q on %s", service, server.ID)
+	}
+
+	if server := m.findServer(target); server != nil {
+		return KindServer, server.ID, nil
+	}
+
+	normalized := strings.ReplaceAll(target, "_", " ")
+	for _, cloud := range infra.Clouds {
+		if strings.EqualFold(cloud.Name, normalized) {
+			return KindCloud, cloud.Name, nil
+		}
+	}
+
+	return "", "", fmt.Errorf("unknown server, service or cloud %q", target)
+}
+
+// findServer finds a server by ID or display name (case-insensitive)
+func (m *Manager) findServer(name string) *config.ServerConfig {
+	if server := m.cfg.GetServer(name); server != nil {
+		return server
+	}
+	for i := range m.cfg.Infrastructure.Clouds {
+		cloud := &m.cfg.Infrastructure.Clouds[i]
+		for j := range cloud.Servers {
+			if strings.EqualFold(cloud.Servers[j].ID, name) || strings.EqualFold(cloud.Servers[j].Name, name) {
+				return &cloud.Servers[j]
+			}
+		}
+	}
+	return nil
+}
+
+// Add creates a silence for target starting now
+func (m *Manager) Add(target string, d time.Duration, reason, createdBy string) (*Silence, error) {
+	if d <= 0 {
+		return nil, fmt.Errorf("duration must be positive")
+	}
+	kind, resolved, err := m.Resolve(target)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	s := &Silence{
+		ID:        m.nextID,
+		Kind:      kind,
+		Target:    resolved,
+		Reason:    reason,
+		CreatedBy: createdBy,
+		StartsAt:  now,
+		EndsAt:    now.Add(d),
+	}
+	m.nextID++
+	m.silences = append(m.silences, s)
+	m.saveLocked()
+
+	copied := *s
+	return &copied, nil
+}
+
+// Expire ends a silence now
+func (m *Manager) Expire(id int) (*Silence, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, s := range m.silences {
+		if s.ID == id {
+			m.silences = append(m.silences[:i], m.silences[i+1:]...)
+			m.saveLocked()
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("silence #%d not found", id)
+}
+
+// Extend moves the end of a silence by d
+func (m *Manager) Extend(id int, d time.Duration) (*Silence, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for _, s := range m.silences {
+		if s.ID == id && s.EndsAt.After(now) {
+			s.EndsAt = s.EndsAt.Add(d)
+			m.saveLocked()
+			copied := *s
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("silence #%d not found", id)
+}
+
+// Active returns manual and scheduled silences active at now, ending soonest first
+func (m *Manager) Active(now time.Time) []Silence {
+	m.mu.Lock()
+	var active []Silence
+	for _, s := range m.silences {
+		if !now.Before(s.StartsAt) && now.Before(s.EndsAt) {
+			active = append(active, *s)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, w := range m.cfg.Infrastructure.Maintenance {
+		start, end, ok := w.ActiveAt(now)
+		if !ok {
+			continue
+		}
+		kind, target, err := m.Resolve(w.Target)
+		if err != nil {
+			continue // target not in current config (e.g. S3 metadata changed)
+		}
+		active = append(active, Silence{
+			Kind:      kind,
+			Target:    target,
+			Reason:    w.Reason,
+			StartsAt:  start,
+			EndsAt:    end,
+			Scheduled: true,
+		})
+	}
+
+	sort.Slice(active, func(i, j int) bool { return active[i].EndsAt.Before(active[j].EndsAt) })
+	return active
+}
+
+// Match returns the longest active silence covering a server (service == "")
+// or a service. Silences of the server and its cloud cover services too.
+func (m *Manager) Match(serverID, service string, now time.Time) (Silence, bool) {
+	cloud := m.cfg.GetServerCloud(serverID)
+
+	var best Silence
+	found := false
+	for _, s := range m.Active(now) {
+		if !s.covers(cloud, serverID, "") && !(service != "" && s.covers(cloud, serverID, service)) {
+			continue
+		}
+		if !found || s.EndsAt.After(best.EndsAt) {
+			best, found = s, true
+		}
+	}
+	return best, found
+}
+
+// IsSilenced reports whether notifications for a server or service are suppressed
+func (m *Manager) IsSilenced(serverID, service string) bool {
+	_, ok := m.Match(serverID, service, time.Now())
+	return ok
+}
+
+// saveLocked writes active silences to the store (errors are logged)
+func (m *Manager) saveLocked() {
+	now := time.Now()
+	kept := m.silences[:0]
+	for _, s := range m.silences {
+		if s.EndsAt.After(now) {
+			kept = append(kept, s)
+		}
+	}
+	m.silences = kept
+
+	if m.store == nil {
+		return
+	}
+	if err := m.store.Save(stateFile, state{NextID: m.nextID, Silences: m.silences}); err != nil {
+		log.Printf("Warning: failed to save silences: %v", err)
+	}
+}
+
+// ParseDuration parses a duration with an optional day suffix ("2d", "1d12h", "90m")
+func ParseDuration(s string) (time.Duration, error) {
+	orig := s
+	var days time.Duration
+	if i := strings.IndexByte(s, 'd'); i > 0 {
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		days = time.Duration(n) * 24 * time.Hour
+		s = s[i+1:]
+		if s == "" {
+			return days, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+	return days + d, nil
+}
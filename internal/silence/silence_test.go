@@ -0,0 +1,214 @@
+package silence
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/config"
+)
+
+// testConfig has two clouds: Production (web, db) and Edge Cloud (vps)
+func testConfig() *config.Config {
+	return &config.Config{
+		Infrastructure: config.InfrastructureConfig{
+			Clouds: []config.CloudConfig{
+				{
+					Name: "Production",
+					Servers: []config.ServerConfig{
+						{ID: "web", Name: "Web Server", Services: []config.ServiceConfig{{Name: "Nginx"}}},
+						{ID: "db", Name: "Database", Probes: []config.ProbeConfig{{Kind: "tcp", Name: "Postgres"}}},
+					},
+				},
+				{
+					Name:    "Edge Cloud",
+					Servers: []config.ServerConfig{{ID: "vps", Name: "VPS"}},
+				},
+			},
+		},
+	}
+}
+
+func TestResolve(t *testing.T) {
+	m := NewManager(testConfig(), nil)
+
+	tests := []struct {
+		target   string
+		kind     string
+		resolved string
+		wantErr  string
+	}{
+		{target: "web", kind: KindServer, resolved: "web"},
+		{target: "web server", kind: KindServer, resolved: "web"},
+		{target: "WEB", kind: KindServer, resolved: "web"},
+		{target: "web/nginx", kind: KindService, resolved: "web/Nginx"},
+		{target: "db/postgres", kind: KindService, resolved: "db/Postgres"},
+		{target: "production", kind: KindCloud, resolved: "Production"},
+		{target: "edge_cloud", kind: KindCloud, resolved: "Edge Cloud"},
+		{target: "web/redis", wantErr: `unknown service "redis" on web`},
+		{target: "mail/smtp", wantErr: `unknown server "mail"`},
+		{target: "mail", wantErr: `unknown server, service or cloud "mail"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			kind, resolved, err := m.Resolve(tt.target)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Resolve() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if kind != tt.kind || resolved != tt.resolved {
+				t.Errorf("Resolve() = %s %s, want %s %s", kind, resolved, tt.kind, tt.resolved)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.Local)
+	hour := func(n int) time.Time { return now.Add(time.Duration(n) * time.Hour) }
+
+	tests := []struct {
+		name     string
+		silences []*Silence
+		windows  []config.MaintenanceWindow
+		serverID string
+		service  string
+		wantID   int  // expected matching silence (0 for scheduled)
+		wantOK   bool // whether a silence matches
+	}{
+		{
+			name:     "no silences",
+			serverID: "web",
+		},
+		{
+			name:     "server silence covers server",
+			silences: []*Silence{{ID: 1, Kind: KindServer, Target: "web", StartsAt: hour(-1), EndsAt: hour(1)}},
+			serverID: "web",
+			wantID:   1, wantOK: true,
+		},
+		{
+			name:     "server silence covers its services",
+			silences: []*Silence{{ID: 1, Kind: KindServer, Target: "web", StartsAt: hour(-1), EndsAt: hour(1)}},
+			serverID: "web", service: "Nginx",
+			wantID: 1, wantOK: true,
+		},
+		{
+			name:     "service silence does not cover server",
+			silences: []*Silence{{ID: 1, Kind: KindService, Target: "web/Nginx", StartsAt: hour(-1), EndsAt: hour(1)}},
+			serverID: "web",
+		},
+		{
+			name:     "service silence covers service",
+			silences: []*Silence{{ID: 1, Kind: KindService, Target: "web/Nginx", StartsAt: hour(-1), EndsAt: hour(1)}},
+			serverID: "web", service: "Nginx",
+			wantID: 1, wantOK: true,
+		},
+		{
+			name:     "service silence does not cover other services",
+			silences: []*Silence{{ID: 1, Kind: KindService, Target: "web/Nginx", StartsAt: hour(-1), EndsAt: hour(1)}},
+			serverID: "web", service: "Redis",
+		},
+		{
+			name:     "cloud silence covers its servers",
+			silences: []*Silence{{ID: 1, Kind: KindCloud, Target: "Production", StartsAt: hour(-1), EndsAt: hour(1)}},
+			serverID: "db",
+			wantID:   1, wantOK: true,
+		},
+		{
+			name:     "cloud silence does not cover other clouds",
+			silences: []*Silence{{ID: 1, Kind: KindCloud, Target: "Production", StartsAt: hour(-1), EndsAt: hour(1)}},
+			serverID: "vps",
+		},
+		{
+			name:     "expired silence",
+			silences: []*Silence{{ID: 1, Kind: KindServer, Target: "web", StartsAt: hour(-2), EndsAt: now}},
+			serverID: "web",
+		},
+		{
+			name: "longest silence wins",
+			silences: []*Silence{
+				{ID: 1, Kind: KindServer, Target: "web", StartsAt: hour(-1), EndsAt: hour(1)},
+				{ID: 2, Kind: KindCloud, Target: "Production", StartsAt: hour(-1), EndsAt: hour(3)},
+				{ID: 3, Kind: KindService, Target: "web/Nginx", StartsAt: hour(-1), EndsAt: hour(2)},
+			},
+			serverID: "web", service: "Nginx",
+			wantID: 2, wantOK: true,
+		},
+		{
+			name:     "active one-off maintenance window",
+			windows:  []config.MaintenanceWindow{{Target: "web", Start: hour(-1), Duration: 2 * time.Hour}},
+			serverID: "web",
+			wantOK:   true,
+		},
+		{
+			name:     "past one-off maintenance window",
+			windows:  []config.MaintenanceWindow{{Target: "web", Start: hour(-3), Duration: 2 * time.Hour}},
+			serverID: "web",
+		},
+		{
+			name:     "active weekly maintenance window",
+			windows:  []config.MaintenanceWindow{{Target: "Production", Weekly: "wed 11:30", Duration: time.Hour}},
+			serverID: "db",
+			wantOK:   true,
+		},
+		{
+			name:     "maintenance window with unknown target",
+			windows:  []config.MaintenanceWindow{{Target: "mail", Start: hour(-1), Duration: 2 * time.Hour}},
+			serverID: "web",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Infrastructure.Maintenance = tt.windows
+			m := NewManager(cfg, nil)
+			m.silences = tt.silences
+
+			got, ok := m.Match(tt.serverID, tt.service, now)
+			if ok != tt.wantOK {
+				t.Fatalf("Match() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && got.ID != tt.wantID {
+				t.Errorf("Match() = #%d, want #%d", got.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "90m", want: 90 * time.Minute},
+		{input: "2h30m", want: 150 * time.Minute},
+		{input: "2d", want: 48 * time.Hour},
+		{input: "1d12h", want: 36 * time.Hour},
+		{input: "", wantErr: true},
+		{input: "d", wantErr: true},
+		{input: "xd", wantErr: true},
+		{input: "1dx", wantErr: true},
+		{input: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.input)
+		if tt.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "invalid duration") {
+				t.Errorf("ParseDuration(%q) error = %v, want invalid duration", tt.input, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v, want %v", tt.input, got, err, tt.want)
+		}
+	}
+}
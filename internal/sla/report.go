@@ -0,0 +1,173 @@
+package sla
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/config"
+	"github.com/scinfra-pro/scinfra-bot/internal/incident"
+)
+
+// Row kinds
+const (
+	KindCloud   = "cloud"
+	KindServer  = "server"
+	KindService = "service"
+)
+
+// Row is the availability of one cloud, server or service
+type Row struct {
+	Kind      string
+	ID        string // cloud name, server ID or "server/service"
+	Name      string
+	Cloud     string
+	Counts    Counts
+	Incidents int
+	MTTR      time.Duration // mean duration of closed incidents (0 if none)
+}
+
+// Report is an availability report for a period
+type Report struct {
+	Period string
+	Start  time.Time
+	End    time.Time
+	Rows   []Row // cloud, then its servers, each followed by its services
+}
+
+// PeriodStart returns the start of a rolling period ending at now
+// (day: 24 hours, week: 7 days, month: 30 days)
+func PeriodStart(period string, now time.Time) (time.Time, error) {
+	switch period {
+	case config.PeriodDay:
+		return now.Add(-24 * time.Hour), nil
+	case config.PeriodWeek:
+		return now.AddDate(0, 0, -7), nil
+	case config.PeriodMonth:
+		return now.AddDate(0, 0, -30), nil
+	}
+	return time.Time{}, fmt.Errorf("unknown period %q (day, week or month)", period)
+}
+
+// Build computes a report from samples and incidents overlapping the period
+func Build(cfg *config.Config, counts map[string]Counts, incidents []incident.Incident, period string, start, end time.Time) Report {
+	report := Report{Period: period, Start: start, End: end}
+
+	for _, cloud := range cfg.Infrastructure.Clouds {
+		cloudRow := Row{Kind: KindCloud, ID: cloud.Name, Name: cloud.Name, Cloud: cloud.Name}
+		var cloudDurations []time.Duration
+		var rows []Row
+
+		for _, server := range cloud.Servers {
+			serverRow := Row{Kind: KindServer, ID: server.ID, Name: server.Name, Cloud: cloud.Name, Counts: counts[server.ID]}
+			cloudRow.Counts.add(serverRow.Counts)
+
+			durations := incidentDurations(incidents, server.ID, "")
+			serverRow.Incidents, serverRow.MTTR = countIncidents(incidents, server.ID, ""), meanDuration(durations)
+			cloudRow.Incidents += serverRow.Incidents
+			cloudDurations = append(cloudDurations, durations...)
+			rows = append(rows, serverRow)
+
+			for _, service := range serviceNames(counts, server.ID) {
+				key := server.ID + "/" + service
+				serviceRow := Row{Kind: KindService, ID: key, Name: service, Cloud: cloud.Name, Counts: counts[key]}
+				durations := incidentDurations(incidents, server.ID, service)
+				serviceRow.Incidents, serviceRow.MTTR = countIncidents(incidents, server.ID, service), meanDuration(durations)
+				cloudRow.Incidents += serviceRow.Incidents
+				cloudDurations = append(cloudDurations, durations...)
+				rows = append(rows, serviceRow)
+			}
+		}
+
+		cloudRow.MTTR = meanDuration(cloudDurations)
+		report.Rows = append(report.Rows, cloudRow)
+		report.Rows = append(report.Rows, rows...)
+	}
+
+	return report
+}
+
+// serviceNames returns services of a server that have samples, sorted
+func serviceNames(counts map[string]Counts, serverID string) []string {
+	var names []string
+	for key := range counts {
+		if service, ok := strings.CutPrefix(key, serverID+"/"); ok {
+			names = append(names, service)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// countIncidents counts incidents of a server (service == "") or service
+func countIncidents(incidents []incident.Incident, serverID, service string) int {
+	n := 0
+	for _, inc := range incidents {
+		if inc.ServerID == serverID && inc.Service == service {
+			n++
+		}
+	}
+	return n
+}
+
+// incidentDurations returns durations of closed incidents of a server or service
+func incidentDurations(incidents []incident.Incident, serverID, service string) []time.Duration {
+	var durations []time.Duration
+	for _, inc := range incidents {
+		if inc.ServerID == serverID && inc.Service == service && !inc.Ongoing() {
+			durations = append(durations, inc.ClosedAt.Sub(inc.OpenedAt))
+		}
+	}
+	return durations
+}
+
+// meanDuration returns the mean of durations (0 if empty)
+func meanDuration(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	var sum time.Duration
+	for _, d := range durations {
+		sum += d
+	}
+	return sum / time.Duration(len(durations))
+}
+
+// CSV encodes the report with one line per row
+func (r *Report) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := []string{"period_start", "period_end", "kind", "cloud", "id", "name",
+		"availability_percent", "samples", "up", "degraded", "down", "incidents", "mttr_seconds"}
+	if err := w.Write(header); err != nil {
+		return nil, fmt.Errorf("write csv: %w", err)
+	}
+
+	for _, row := range r.Rows {
+		availability := ""
+		if pct, ok := row.Counts.Availability(); ok {
+			availability = strconv.FormatFloat(pct, 'f', 3, 64)
+		}
+		record := []string{
+			r.Start.Format(time.RFC3339), r.End.Format(time.RFC3339),
+			row.Kind, row.Cloud, row.ID, row.Name, availability,
+			strconv.Itoa(row.Counts.Total()), strconv.Itoa(row.Counts.Up),
+			strconv.Itoa(row.Counts.Degraded), strconv.Itoa(row.Counts.Down),
+			strconv.Itoa(row.Incidents), strconv.Itoa(int(row.MTTR.Seconds())),
+		}
+		if err := w.Write(record); err != nil {
+			return nil, fmt.Errorf("write csv: %w", err)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("write csv: %w", err)
+	}
+	return buf.Bytes(), nil
+}
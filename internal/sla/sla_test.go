@@ -0,0 +1,174 @@
+package sla
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/config"
+	"github.com/scinfra-pro/scinfra-bot/internal/health"
+	"github.com/scinfra-pro/scinfra-bot/internal/incident"
+)
+
+func TestCountsAvailability(t *testing.T) {
+	tests := []struct {
+		counts Counts
+		want   float64
+		wantOK bool
+	}{
+		{counts: Counts{}, wantOK: false},
+		{counts: Counts{Up: 10}, want: 100, wantOK: true},
+		{counts: Counts{Up: 6, Degraded: 2, Down: 2}, want: 80, wantOK: true},
+		{counts: Counts{Degraded: 1}, want: 100, wantOK: true},
+		{counts: Counts{Down: 3}, want: 0, wantOK: true},
+		{counts: Counts{Up: 999, Down: 1}, want: 99.9, wantOK: true},
+	}
+	for _, tt := range tests {
+		got, ok := tt.counts.Availability()
+		if ok != tt.wantOK || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%+v.Availability() = %v, %v, want %v, %v", tt.counts, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestPeriodStart(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		period  string
+		want    time.Time
+		wantErr bool
+	}{
+		{period: config.PeriodDay, want: time.Date(2026, 3, 30, 12, 0, 0, 0, time.UTC)},
+		{period: config.PeriodWeek, want: time.Date(2026, 3, 24, 12, 0, 0, 0, time.UTC)},
+		{period: config.PeriodMonth, want: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{period: "year", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := PeriodStart(tt.period, now)
+		if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+			t.Errorf("PeriodStart(%q) = %v, %v, want %v (error %v)", tt.period, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// Server statuses at each level; the degraded server has a down warning service
+var (
+	serverUp       = &health.ServerStatus{ID: "web", IsUp: true, Services: []health.ServiceStatus{{Name: "Nginx", IsUp: true}}}
+	serverDegraded = &health.ServerStatus{ID: "web", IsUp: true, Services: []health.ServiceStatus{{Name: "Nginx", IsUp: false}}}
+	serverDown     = &health.ServerStatus{ID: "web", IsUp: false}
+)
+
+func TestTrackerCounts(t *testing.T) {
+	hour := time.Now().Truncate(time.Hour).Add(-5 * time.Hour)
+	at := func(h int, m int) time.Time {
+		return hour.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute)
+	}
+
+	samples := []struct {
+		status *health.ServerStatus
+		at     time.Time
+	}{
+		{serverUp, at(0, 0)},
+		{serverUp, at(0, 30)},
+		{serverDegraded, at(0, 59)},
+		{serverDown, at(1, 10)},
+		{serverUp, at(1, 20)},
+		{serverUp, at(3, 0)}, // current hour
+	}
+
+	tr := NewTracker(nil, 24*time.Hour)
+	for _, s := range samples {
+		tr.RecordSample([]*health.ServerStatus{s.status}, s.at)
+	}
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		want       map[string]Counts
+	}{
+		{
+			name:  "all hours",
+			start: at(0, 0), end: at(4, 0),
+			want: map[string]Counts{
+				"web":       {Up: 4, Degraded: 1, Down: 1},
+				"web/Nginx": {Up: 4, Down: 1},
+			},
+		},
+		{
+			name:  "first hour only",
+			start: at(0, 0), end: at(1, 0),
+			want: map[string]Counts{
+				"web":       {Up: 2, Degraded: 1},
+				"web/Nginx": {Up: 2, Down: 1},
+			},
+		},
+		{
+			name:  "partially overlapped hours count whole",
+			start: at(0, 45), end: at(1, 5),
+			want: map[string]Counts{
+				"web":       {Up: 3, Degraded: 1, Down: 1},
+				"web/Nginx": {Up: 3, Down: 1},
+			},
+		},
+		{
+			name:  "current hour",
+			start: at(3, 0), end: at(4, 0),
+			want: map[string]Counts{
+				"web":       {Up: 1},
+				"web/Nginx": {Up: 1},
+			},
+		},
+		{
+			name:  "no samples",
+			start: at(2, 0), end: at(3, 0),
+			want: map[string]Counts{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tr.Counts(tt.start, tt.end); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Counts() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	cfg := &config.Config{
+		Infrastructure: config.InfrastructureConfig{
+			Clouds: []config.CloudConfig{{
+				Name: "Production",
+				Servers: []config.ServerConfig{
+					{ID: "web", Name: "Web"},
+					{ID: "db", Name: "DB"},
+				},
+			}},
+		},
+	}
+	counts := map[string]Counts{
+		"web":       {Up: 9, Down: 1},
+		"web/Nginx": {Up: 10},
+		"db":        {Up: 10},
+	}
+	opened := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	incidents := []incident.Incident{
+		{ServerID: "web", OpenedAt: opened, ClosedAt: opened.Add(10 * time.Minute)},
+		{ServerID: "web", OpenedAt: opened, ClosedAt: opened.Add(30 * time.Minute)},
+		{ServerID: "web", Service: "Nginx", OpenedAt: opened}, // ongoing: counted, no MTTR
+		{ServerID: "db", OpenedAt: opened, ClosedAt: opened.Add(50 * time.Minute)},
+	}
+
+	report := Build(cfg, counts, incidents, config.PeriodDay, opened, opened.Add(24*time.Hour))
+
+	want := []Row{
+		{Kind: KindCloud, ID: "Production", Name: "Production", Cloud: "Production", Counts: Counts{Up: 19, Down: 1}, Incidents: 4, MTTR: 30 * time.Minute},
+		{Kind: KindServer, ID: "web", Name: "Web", Cloud: "Production", Counts: Counts{Up: 9, Down: 1}, Incidents: 2, MTTR: 20 * time.Minute},
+		{Kind: KindService, ID: "web/Nginx", Name: "Nginx", Cloud: "Production", Counts: Counts{Up: 10}, Incidents: 1},
+		{Kind: KindServer, ID: "db", Name: "DB", Cloud: "Production", Counts: Counts{Up: 10}, Incidents: 1, MTTR: 50 * time.Minute},
+	}
+	if !reflect.DeepEqual(report.Rows, want) {
+		t.Errorf("Build() rows = %+v, want %+v", report.Rows, want)
+	}
+}
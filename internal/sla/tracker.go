@@ -0,0 +1,196 @@
+// Package sla computes availability reports from persisted health samples
+package sla
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/health"
+	"github.com/scinfra-pro/scinfra-bot/internal/store"
+)
+
+// Store files: completed hours are appended to the log, the current hour is
+// saved as a document after every sample
+const (
+	samplesLog = "availability.jsonl"
+	currentDoc = "availability_current.json"
+)
+
+// Counts are the number of health samples per level
+type Counts struct {
+	Up       int `json:"up,omitempty"`
+	Degraded int `json:"degraded,omitempty"`
+	Down     int `json:"down,omitempty"`
+}
+
+// Total returns the number of samples
+func (c Counts) Total() int {
+	return c.Up + c.Degraded + c.Down
+}
+
+// Availability returns the percentage of samples that were not down
+// (false if there are no samples)
+func (c Counts) Availability() (float64, bool) {
+	if c.Total() == 0 {
+		return 0, false
+	}
+	return float64(c.Up+c.Degraded) / float64(c.Total()) * 100, true
+}
+
+// add adds the samples of o
+func (c *Counts) add(o Counts) {
+	c.Up += o.Up
+	c.Degraded += o.Degraded
+	c.Down += o.Down
+}
+
+// observe counts one sample at level
+func (c *Counts) observe(level health.StatusLevel) {
+	switch level {
+	case health.StatusUp:
+		c.Up++
+	case health.StatusDegraded:
+		c.Degraded++
+	default:
+		c.Down++
+	}
+}
+
+// bucket holds the samples of one hour per target
+// (key: serverID or serverID/service)
+type bucket struct {
+	Hour    time.Time         `json:"hour"`
+	Targets map[string]Counts `json:"targets"`
+}
+
+// Tracker aggregates health samples into hourly buckets kept in the store
+type Tracker struct {
+	store     *store.Store // nil keeps samples in memory only
+	retention time.Duration
+
+	mu      sync.Mutex
+	buckets []*bucket // completed hours, oldest first
+	current *bucket
+}
+
+// NewTracker creates a tracker, loading samples from st
+// Hours older than retention are dropped
+func NewTracker(st *store.Store, retention time.Duration) *Tracker {
+	t := &Tracker{store: st, retention: retention}
+	if st == nil {
+		return t
+	}
+
+	cutoff := time.Now().Add(-retention)
+	err := st.Scan(samplesLog, func(line []byte) error {
+		var b bucket
+		if err := json.Unmarshal(line, &b); err != nil {
+			return err
+		}
+		if b.Hour.After(cutoff) {
+			t.buckets = append(t.buckets, &b)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Warning: failed to load availability samples: %v", err)
+	}
+
+	var current bucket
+	if err := st.Load(currentDoc, &current); err == nil {
+		t.current = &current
+	} else if !errors.Is(err, store.ErrNotFound) {
+		log.Printf("Warning: failed to load availability samples: %v", err)
+	}
+	log.Printf("Loaded %d hours of availability samples", len(t.buckets))
+
+	values := make([]any, 0, len(t.buckets))
+	for _, b := range t.buckets {
+		values = append(values, b)
+	}
+	if err := st.Rewrite(samplesLog, values); err != nil {
+		log.Printf("Warning: failed to compact availability samples: %v", err)
+	}
+	return t
+}
+
+// RecordSample implements health.Sampler
+// Servers count at their evaluated level, services as up or down
+func (t *Tracker) RecordSample(statuses []*health.ServerStatus, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	hour := at.Truncate(time.Hour)
+	if t.current != nil && !t.current.Hour.Equal(hour) {
+		t.flushLocked()
+	}
+	if t.current == nil {
+		t.current = &bucket{Hour: hour, Targets: make(map[string]Counts)}
+	}
+
+	for _, status := range statuses {
+		counts := t.current.Targets[status.ID]
+		counts.observe(status.GetStatusLevel())
+		t.current.Targets[status.ID] = counts
+
+		for _, svc := range status.Services {
+			key := status.ID + "/" + svc.Name
+			counts := t.current.Targets[key]
+			if svc.IsUp {
+				counts.observe(health.StatusUp)
+			} else {
+				counts.observe(health.StatusDown)
+			}
+			t.current.Targets[key] = counts
+		}
+	}
+
+	if t.store != nil {
+		if err := t.store.Save(currentDoc, t.current); err != nil {
+			log.Printf("Warning: failed to save availability samples: %v", err)
+		}
+	}
+}
+
+// flushLocked moves the current hour to the completed buckets and the log
+func (t *Tracker) flushLocked() {
+	t.buckets = append(t.buckets, t.current)
+	if t.store != nil {
+		if err := t.store.Append(samplesLog, t.current); err != nil {
+			log.Printf("Warning: failed to save availability samples: %v", err)
+		}
+	}
+	t.current = nil
+
+	cutoff := time.Now().Add(-t.retention)
+	for len(t.buckets) > 0 && t.buckets[0].Hour.Before(cutoff) {
+		t.buckets = t.buckets[1:]
+	}
+}
+
+// Counts returns the samples per target in hours overlapping [start, end)
+func (t *Tracker) Counts(start, end time.Time) map[string]Counts {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := make(map[string]Counts)
+	add := func(b *bucket) {
+		if b.Hour.Add(time.Hour).After(start) && b.Hour.Before(end) {
+			for key, c := range b.Targets {
+				sum := result[key]
+				sum.add(c)
+				result[key] = sum
+			}
+		}
+	}
+	for _, b := range t.buckets {
+		add(b)
+	}
+	if t.current != nil {
+		add(t.current)
+	}
+	return result
+}
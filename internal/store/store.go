@@ -0,0 +1,148 @@
+// Package store persists bot state as files in a local data directory
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrNotFound is returned when a document does not exist yet
+var ErrNotFound = errors.New("not found")
+
+// Store keeps JSON documents (name.json) and append-only JSON Lines logs
+// (name.jsonl) in a directory. Writes are serialised; documents are replaced
+// atomically.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// Open creates the data directory if needed and returns a store for it
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Dir returns the data directory
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// path returns the file path for a name
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, name)
+}
+
+// Load decodes a JSON document into v (ErrNotFound if it does not exist)
+func (s *Store) Load(name string, v any) error {
+	data, err := os.ReadFile(s.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("read %s: %w", name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// Save encodes v as a JSON document, replacing the previous one atomically
+func (s *Store) Save(name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeAtomic(name, data)
+}
+
+// Append adds v as one line to a JSON Lines log
+func (s *Store) Append(name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path(name), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", name, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("append %s: %w", name, err)
+	}
+	return nil
+}
+
+// Scan calls fn for each line of a JSON Lines log (no error if it does not exist)
+// Lines that fn rejects are skipped, so a torn last line does not lose the log
+func (s *Store) Scan(name string, fn func(line []byte) error) error {
+	f, err := os.Open(s.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open %s: %w", name, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		_ = fn(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read %s: %w", name, err)
+	}
+	return nil
+}
+
+// Rewrite replaces a JSON Lines log with the given values (used for compaction)
+func (s *Store) Rewrite(name string, values []any) error {
+	var buf bytes.Buffer
+	for _, v := range values {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", name, err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeAtomic(name, buf.Bytes())
+}
+
+// writeAtomic writes data to a temporary file and renames it over name
+func (s *Store) writeAtomic(name string, data []byte) error {
+	path := s.path(name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o640); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename %s: %w", name, err)
+	}
+	return nil
+}
@@ -2,17 +2,23 @@ package switchgate
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net"
 	"os"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/prometheus"
 )
 
 // Client provides SSH access to VPS with switch-gate
@@ -198,10 +204,11 @@ func (c *Client) getAgentAuth() ssh.AuthMethod {
 }
 
 // exec runs command on VPS via SSH with ProxyJump
-func (c *Client) exec(cmd string) (string, error) {
+// Cancelling ctx closes the connection and aborts the command
+func (c *Client) exec(ctx context.Context, cmd string) (string, error) {
 	start := time.Now()
 
-	result, err := c.execInternal(cmd)
+	result, err := c.execInternal(ctx, cmd)
 
 	// Record statistics
 	latency := time.Since(start)
@@ -211,7 +218,7 @@ func (c *Client) exec(cmd string) (string, error) {
 }
 
 // execInternal performs the actual SSH command execution
-func (c *Client) execInternal(cmd string) (string, error) {
+func (c *Client) execInternal(ctx context.Context, cmd string) (string, error) {
 	// Parse jump host
 	jumpUser := "master"
 	jumpAddr := c.jumpHost
@@ -228,23 +235,36 @@ func (c *Client) execInternal(cmd string) (string, error) {
 		Timeout:         10 * time.Second,
 	}
 
-	jumpConn, err := ssh.Dial("tcp", jumpAddr+":22", jumpConfig)
+	dialer := net.Dialer{Timeout: jumpConfig.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", jumpAddr+":22")
 	if err != nil {
 		return "", fmt.Errorf("dial jump host: %w", err)
 	}
+	defer func() { _ = conn.Close() }()
+
+	// Closing the jump connection tears down the handshakes, the target
+	// connection and the session on top of it
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	jumpNcc, jumpChans, jumpReqs, err := ssh.NewClientConn(conn, jumpAddr+":22", jumpConfig)
+	if err != nil {
+		return "", fmt.Errorf("dial jump host: %w", contextError(ctx, err))
+	}
+	jumpConn := ssh.NewClient(jumpNcc, jumpChans, jumpReqs)
 	defer func() { _ = jumpConn.Close() }()
 
 	// Connect to target through jump host
 	targetConn, err := jumpConn.Dial("tcp", c.targetIP+":22")
 	if err != nil {
-		return "", fmt.Errorf("dial target via jump: %w", err)
+		return "", fmt.Errorf("dial target via jump: %w", contextError(ctx, err))
 	}
 	defer func() { _ = targetConn.Close() }()
 
 	// Create SSH connection to target
 	ncc, chans, reqs, err := ssh.NewClientConn(targetConn, c.targetIP+":22", c.sshConfig)
 	if err != nil {
-		return "", fmt.Errorf("ssh client conn: %w", err)
+		return "", fmt.Errorf("ssh client conn: %w", contextError(ctx, err))
 	}
 	targetClient := ssh.NewClient(ncc, chans, reqs)
 	defer func() { _ = targetClient.Close() }()
@@ -252,7 +272,7 @@ func (c *Client) execInternal(cmd string) (string, error) {
 	// Create session
 	session, err := targetClient.NewSession()
 	if err != nil {
-		return "", fmt.Errorf("new session: %w", err)
+		return "", fmt.Errorf("new session: %w", contextError(ctx, err))
 	}
 	defer func() { _ = session.Close() }()
 
@@ -262,16 +282,36 @@ func (c *Client) execInternal(cmd string) (string, error) {
 	session.Stderr = &stderr
 
 	if err := session.Run(cmd); err != nil {
-		return "", fmt.Errorf("run command: %w (stderr: %s)", err, stderr.String())
+		return "", fmt.Errorf("run command: %w (stderr: %s)", contextError(ctx, err), stderr.String())
 	}
 
 	return stdout.String(), nil
 }
 
+// contextError returns the context error if ctx is done (the connection was
+// closed because of it), or err otherwise
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// Run executes an arbitrary command on the VPS and returns its stdout
+// (used by ssh-command health probes)
+func (c *Client) Run(ctx context.Context, cmd string) (string, error) {
+	return c.exec(ctx, cmd)
+}
+
 // GetStatus returns switch-gate status (fast, no health check)
 func (c *Client) GetStatus() (*Status, error) {
+	return c.GetStatusContext(context.Background())
+}
+
+// GetStatusContext is GetStatus with a context for cancellation
+func (c *Client) GetStatusContext(ctx context.Context) (*Status, error) {
 	cmd := fmt.Sprintf("curl -s http://127.0.0.1:%d/status", c.apiPort)
-	output, err := c.exec(cmd)
+	output, err := c.exec(ctx, cmd)
 	if err != nil {
 		log.Printf("[%s] GetStatus: exec error: %v", c.name, err)
 		return nil, err
@@ -293,7 +333,7 @@ func (c *Client) GetStatus() (*Status, error) {
 // This takes ~5 seconds longer due to the connectivity test
 func (c *Client) GetStatusWithCheck() (*Status, error) {
 	cmd := fmt.Sprintf("curl -s 'http://127.0.0.1:%d/status?check=true'", c.apiPort)
-	output, err := c.exec(cmd)
+	output, err := c.exec(context.Background(), cmd)
 	if err != nil {
 		return nil, err
 	}
@@ -311,7 +351,7 @@ func (c *Client) SetMode(mode string) error {
 	cmd := fmt.Sprintf("curl -s -X POST http://127.0.0.1:%d/mode/%s", c.apiPort, mode)
 	log.Printf("[%s] SetMode(%s): executing %s", c.name, mode, cmd)
 
-	output, err := c.exec(cmd)
+	output, err := c.exec(context.Background(), cmd)
 	if err != nil {
 		log.Printf("[%s] SetMode(%s): exec error: %v", c.name, mode, err)
 		return err
@@ -339,7 +379,7 @@ func (c *Client) SetMode(mode string) error {
 func (c *Client) GetExternalIP() (string, error) {
 	// Use switch-gate SOCKS proxy to get external IP
 	cmd := "curl -s -x socks5h://127.0.0.1:18388 --max-time 10 api.ipify.org"
-	output, err := c.exec(cmd)
+	output, err := c.exec(context.Background(), cmd)
 	if err != nil {
 		return "", err
 	}
@@ -348,10 +388,143 @@ func (c *Client) GetExternalIP() (string, error) {
 
 // Restart restarts the switch-gate service via systemctl
 func (c *Client) Restart() error {
-	_, err := c.exec("systemctl restart switch-gate")
+	_, err := c.exec(context.Background(), "systemctl restart switch-gate")
 	return err
 }
 
+// ServiceCheck describes a service to check on the VPS
+type ServiceCheck struct {
+	Unit string // systemd unit (empty to skip systemctl check)
+	Port int    // local TCP port (0 to skip port check)
+}
+
+// ServiceResult represents the outcome of a service check
+type ServiceResult struct {
+	Unit       string
+	Port       int
+	UnitState  string // systemctl is-active output ("active", "inactive", "failed", ...)
+	PortOpen   bool
+	PortError  string // "connection refused", "connect timeout"
+	CheckError string // check could not be performed
+}
+
+// IsUp returns true if both the unit and the port checks passed
+func (r ServiceResult) IsUp() bool {
+	if r.CheckError != "" {
+		return false
+	}
+	if r.Unit != "" && r.UnitState != "active" {
+		return false
+	}
+	if r.Port > 0 && !r.PortOpen {
+		return false
+	}
+	return true
+}
+
+// Error returns the failure reason (empty if service is up)
+func (r ServiceResult) Error() string {
+	if r.CheckError != "" {
+		return r.CheckError
+	}
+
+	var reasons []string
+	if r.Unit != "" && r.UnitState != "active" {
+		state := r.UnitState
+		if state == "" {
+			state = "unknown"
+		}
+		reasons = append(reasons, fmt.Sprintf("unit %s %s", r.Unit, state))
+	}
+	if r.Port > 0 && !r.PortOpen {
+		reasons = append(reasons, fmt.Sprintf("port %d: %s", r.Port, r.PortError))
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// validUnitName matches safe systemd unit names (no shell metacharacters)
+var validUnitName = regexp.MustCompile(`^[A-Za-z0-9@._:-]+$`)
+
+// portCheckTimeout is the timeout for local TCP connect on the VPS
+const portCheckTimeout = 3 * time.Second
+
+// CheckServices checks systemd units and local TCP ports on the VPS
+// All checks run in a single SSH session. An error is returned only if the
+// SSH command itself failed; per-service failures are reported in results.
+func (c *Client) CheckServices(ctx context.Context, checks []ServiceCheck) ([]ServiceResult, error) {
+	results := make([]ServiceResult, len(checks))
+
+	var script strings.Builder
+	for i, check := range checks {
+		results[i] = ServiceResult{Unit: check.Unit, Port: check.Port}
+
+		if check.Unit != "" && !validUnitName.MatchString(check.Unit) {
+			results[i].CheckError = fmt.Sprintf("invalid unit name: %q", check.Unit)
+			continue
+		}
+
+		// Output format: <index>|<unit state>|<port state>
+		unitCmd := "echo"
+		if check.Unit != "" {
+			unitCmd = fmt.Sprintf("systemctl is-active '%s' 2>/dev/null", check.Unit)
+		}
+		portCmd := "echo skip"
+		if check.Port > 0 {
+			portCmd = fmt.Sprintf(
+				`if timeout %d bash -c '</dev/tcp/127.0.0.1/%d' 2>/dev/null; then echo open; else echo "closed:$?"; fi`,
+				int(portCheckTimeout.Seconds()), check.Port,
+			)
+		}
+		script.WriteString(fmt.Sprintf(`echo "%d|$(%s)|$(%s)"; `, i, unitCmd, portCmd))
+	}
+
+	if script.Len() == 0 {
+		return results, nil
+	}
+
+	output, err := c.exec(ctx, script.String())
+	if err != nil {
+		return nil, fmt.Errorf("check services: %w", err)
+	}
+
+	seen := make(map[int]bool)
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), "|", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		idx, err := strconv.Atoi(parts[0])
+		if err != nil || idx < 0 || idx >= len(results) {
+			continue
+		}
+		seen[idx] = true
+
+		r := &results[idx]
+		if r.Unit != "" {
+			r.UnitState = strings.TrimSpace(parts[1])
+		}
+
+		portState := strings.TrimSpace(parts[2])
+		switch {
+		case portState == "open", portState == "skip":
+			r.PortOpen = true
+		case portState == "closed:124":
+			r.PortError = "connect timeout"
+		default:
+			r.PortError = "connection refused"
+		}
+	}
+
+	// Checks without output line (e.g. command aborted)
+	for i := range results {
+		if !seen[i] && results[i].CheckError == "" {
+			results[i].CheckError = "no check output"
+		}
+	}
+
+	return results, nil
+}
+
 // GetModeIcon returns emoji for mode
 func GetModeIcon(mode string) string {
 	switch strings.ToLower(mode) {
@@ -378,74 +551,219 @@ type NodeMetrics struct {
 	DiskUsedBytes   float64
 	DiskTotalBytes  float64
 
-	// CPU (load average as proxy)
+	// All mounted filesystems (pseudo filesystems excluded), sorted by mountpoint
+	Filesystems []FilesystemMetrics
+
+	// CPU utilisation from two node_cpu_seconds_total samples
+	CPUCores       int
+	CPUUsedPercent float64
+
+	// Load average
 	Load1  float64
 	Load15 float64
+
+	// Network throughput summed over physical interfaces (bytes per second)
+	NetworkRxBytesPerSec float64
+	NetworkTxBytesPerSec float64
+}
+
+// FilesystemMetrics represents usage of a single mounted filesystem
+type FilesystemMetrics struct {
+	Mountpoint  string
+	Device      string
+	FSType      string
+	SizeBytes   float64
+	AvailBytes  float64
+	UsedBytes   float64
+	UsedPercent float64
 }
 
+// nodeExporterURL is the local node_exporter endpoint on the VPS
+const nodeExporterURL = "http://127.0.0.1:9100/metrics"
+
+// nodeSampleInterval is the gap between the two node_exporter scrapes used for rates
+const nodeSampleInterval = 2 * time.Second
+
+// nodeSampleSeparator separates the two scrapes in the SSH command output
+const nodeSampleSeparator = "# ---- scinfra-bot sample separator ----"
+
 // GetNodeMetrics fetches system metrics from node_exporter via SSH
-func (c *Client) GetNodeMetrics() (*NodeMetrics, error) {
-	// Fetch specific metrics we need
-	cmd := `curl -s http://127.0.0.1:9100/metrics | grep -E '^(node_memory_MemTotal_bytes|node_memory_MemAvailable_bytes|node_filesystem_size_bytes\{.*mountpoint="/"\}|node_filesystem_avail_bytes\{.*mountpoint="/"\}|node_load1 |node_load15 )'`
-	output, err := c.exec(cmd)
+// node_exporter is scraped twice in a single SSH session so that CPU and
+// network counters can be turned into rates
+func (c *Client) GetNodeMetrics(ctx context.Context) (*NodeMetrics, error) {
+	cmd := fmt.Sprintf("curl -sf %[1]s && echo '%[2]s' && sleep %[3]d && curl -sf %[1]s",
+		nodeExporterURL, nodeSampleSeparator, int(nodeSampleInterval.Seconds()))
+	output, err := c.exec(ctx, cmd)
 	if err != nil {
 		return nil, fmt.Errorf("fetch node metrics: %w", err)
 	}
 
-	metrics := &NodeMetrics{}
-
-	var memTotal, memAvail, diskSize, diskAvail float64
+	firstText, secondText, found := strings.Cut(output, nodeSampleSeparator)
+	if !found {
+		return nil, fmt.Errorf("fetch node metrics: incomplete output")
+	}
 
-	for _, line := range strings.Split(output, "\n") {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
+	first, err := prometheus.ParseText(strings.NewReader(firstText))
+	if err != nil {
+		return nil, fmt.Errorf("parse node metrics: %w", err)
+	}
+	second, err := prometheus.ParseText(strings.NewReader(secondText))
+	if err != nil {
+		return nil, fmt.Errorf("parse node metrics: %w", err)
+	}
 
-		// Parse Prometheus format: metric_name{labels} value
-		parts := strings.Fields(line)
-		if len(parts) < 2 {
-			continue
-		}
+	return computeNodeMetrics(first, second), nil
+}
 
-		metricName := parts[0]
-		valueStr := parts[len(parts)-1]
+// computeNodeMetrics builds NodeMetrics from two consecutive scrapes
+// Gauges are taken from the second scrape, counters are turned into rates
+func computeNodeMetrics(first, second []prometheus.Sample) *NodeMetrics {
+	metrics := &NodeMetrics{}
 
-		var value float64
-		_, _ = fmt.Sscanf(valueStr, "%f", &value)
+	// Elapsed time between scrapes (node_time_seconds is exact, fall back to sleep interval)
+	elapsed := nodeSampleInterval.Seconds()
+	if t1, ok := findValue(first, "node_time_seconds"); ok {
+		if t2, ok := findValue(second, "node_time_seconds"); ok && t2 > t1 {
+			elapsed = t2 - t1
+		}
+	}
 
-		switch {
-		case strings.HasPrefix(metricName, "node_memory_MemTotal_bytes"):
-			memTotal = value
-		case strings.HasPrefix(metricName, "node_memory_MemAvailable_bytes"):
-			memAvail = value
-		case strings.HasPrefix(metricName, "node_filesystem_size_bytes"):
-			if strings.Contains(metricName, `mountpoint="/"`) {
-				diskSize = value
+	var memTotal, memAvail float64
+	fsByMount := make(map[string]*FilesystemMetrics)
+
+	for _, s := range second {
+		switch s.Name {
+		case "node_memory_MemTotal_bytes":
+			memTotal = s.Value
+		case "node_memory_MemAvailable_bytes":
+			memAvail = s.Value
+		case "node_load1":
+			metrics.Load1 = s.Value
+		case "node_load15":
+			metrics.Load15 = s.Value
+		case "node_filesystem_size_bytes", "node_filesystem_avail_bytes":
+			if prometheus.IsPseudoFS(s.Labels["fstype"]) {
+				continue
 			}
-		case strings.HasPrefix(metricName, "node_filesystem_avail_bytes"):
-			if strings.Contains(metricName, `mountpoint="/"`) {
-				diskAvail = value
+			mount := s.Labels["mountpoint"]
+			fs, ok := fsByMount[mount]
+			if !ok {
+				fs = &FilesystemMetrics{
+					Mountpoint: mount,
+					Device:     s.Labels["device"],
+					FSType:     s.Labels["fstype"],
+				}
+				fsByMount[mount] = fs
+			}
+			if s.Name == "node_filesystem_size_bytes" {
+				fs.SizeBytes = s.Value
+			} else {
+				fs.AvailBytes = s.Value
 			}
-		case metricName == "node_load1":
-			metrics.Load1 = value
-		case metricName == "node_load15":
-			metrics.Load15 = value
 		}
 	}
 
-	// Calculate percentages
+	// Memory
 	if memTotal > 0 {
 		metrics.MemoryTotalBytes = memTotal
 		metrics.MemoryUsedBytes = memTotal - memAvail
 		metrics.MemoryUsedPercent = (metrics.MemoryUsedBytes / memTotal) * 100
 	}
 
-	if diskSize > 0 {
-		metrics.DiskTotalBytes = diskSize
-		metrics.DiskUsedBytes = diskSize - diskAvail
-		metrics.DiskUsedPercent = (metrics.DiskUsedBytes / diskSize) * 100
+	// Filesystems
+	for _, fs := range fsByMount {
+		if fs.SizeBytes <= 0 {
+			continue
+		}
+		fs.UsedBytes = fs.SizeBytes - fs.AvailBytes
+		fs.UsedPercent = (fs.UsedBytes / fs.SizeBytes) * 100
+		metrics.Filesystems = append(metrics.Filesystems, *fs)
+
+		if fs.Mountpoint == "/" {
+			metrics.DiskTotalBytes = fs.SizeBytes
+			metrics.DiskUsedBytes = fs.UsedBytes
+			metrics.DiskUsedPercent = fs.UsedPercent
+		}
+	}
+	sort.Slice(metrics.Filesystems, func(i, j int) bool {
+		return metrics.Filesystems[i].Mountpoint < metrics.Filesystems[j].Mountpoint
+	})
+
+	// CPU: idle seconds per second across all cores, normalised by core count
+	idle1, _ := sumCPUIdle(first)
+	idle2, cores := sumCPUIdle(second)
+	metrics.CPUCores = cores
+	if cores > 0 && elapsed > 0 && idle2 >= idle1 {
+		idleRate := (idle2 - idle1) / elapsed
+		metrics.CPUUsedPercent = clampPercent((1 - idleRate/float64(cores)) * 100)
+	}
+
+	// Network
+	metrics.NetworkRxBytesPerSec = counterRate(first, second, "node_network_receive_bytes_total", elapsed)
+	metrics.NetworkTxBytesPerSec = counterRate(first, second, "node_network_transmit_bytes_total", elapsed)
+
+	return metrics
+}
+
+// findValue returns the value of the first sample with given name
+func findValue(samples []prometheus.Sample, name string) (float64, bool) {
+	for _, s := range samples {
+		if s.Name == name {
+			return s.Value, true
+		}
+	}
+	return 0, false
+}
+
+// sumCPUIdle returns total idle seconds across all cores and the core count
+func sumCPUIdle(samples []prometheus.Sample) (float64, int) {
+	var idle float64
+	cores := 0
+	for _, s := range samples {
+		if s.Name == "node_cpu_seconds_total" && s.Labels["mode"] == "idle" {
+			idle += s.Value
+			cores++
+		}
+	}
+	return idle, cores
+}
+
+// counterRate returns per-second rate of a per-device counter summed over
+// physical interfaces. Devices whose counter reset between scrapes are skipped.
+func counterRate(first, second []prometheus.Sample, name string, elapsed float64) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+
+	before := make(map[string]float64)
+	for _, s := range first {
+		if s.Name == name {
+			before[s.Labels["device"]] = s.Value
+		}
 	}
 
-	return metrics, nil
+	var delta float64
+	for _, s := range second {
+		if s.Name != name || prometheus.IsVirtualNetDevice(s.Labels["device"]) {
+			continue
+		}
+		prev, ok := before[s.Labels["device"]]
+		if !ok || s.Value < prev {
+			continue
+		}
+		delta += s.Value - prev
+	}
+
+	return delta / elapsed
+}
+
+// clampPercent limits value to 0-100 range
+func clampPercent(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 100 {
+		return 100
+	}
+	return v
 }
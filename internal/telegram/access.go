@@ -0,0 +1,172 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/config"
+)
+
+// commandRoles are the minimum roles of commands (not listed: viewer)
+// Keys are command names; /upstream_<name> and /restart_sg_<name> use "upstream" and "restart_sg"
+var commandRoles = map[string]string{
+	"edge":        config.RoleOperator,
+	"edge_direct": config.RoleOperator,
+	"edge_full":   config.RoleOperator,
+	"edge_split":  config.RoleOperator,
+	"upstream":    config.RoleOperator,
+	"vps":         config.RoleOperator,
+	"vps_direct":  config.RoleOperator,
+	"vps_warp":    config.RoleOperator,
+	"vps_home":    config.RoleOperator,
+	"restart":     config.RoleOperator,
+	"restart_sg":  config.RoleOperator,
+	"silence":     config.RoleOperator,
+	"promql":      config.RoleAdmin,
+}
+
+// statusCommands only show the current state when run without arguments,
+// which every role may do
+var statusCommands = map[string]bool{"edge": true, "upstream": true, "vps": true}
+
+// callbackRoles are the minimum roles of callbacks (not listed: viewer)
+// Keys are "category:action" or "category:*" for all actions of a category
+var callbackRoles = map[string]string{
+	"edge:*":         config.RoleOperator,
+	"upstream:*":     config.RoleOperator,
+	"vps:*":          config.RoleOperator,
+	"restart:*":      config.RoleOperator,
+	"silence:expire": config.RoleOperator,
+	"silence:extend": config.RoleOperator,
+}
+
+// roleIcons are shown next to role names
+var roleIcons = map[string]string{
+	config.RoleViewer:   "👁",
+	config.RoleOperator: "🛠",
+	config.RoleAdmin:    "👑",
+}
+
+// roleDescriptions describe what a role may do (for /whoami)
+var roleDescriptions = map[string]string{
+	config.RoleViewer:   "View status, infrastructure, graphs and reports",
+	config.RoleOperator: "Viewer, plus switch edge, upstream and VPS modes, restart switch-gate and silence alerts",
+	config.RoleAdmin:    "Operator, plus ad-hoc PromQL queries",
+}
+
+// commandKey returns the permission key of a command (dynamic commands share one key)
+func (b *Bot) commandKey(cmd string) string {
+	if name, ok := strings.CutPrefix(cmd, "upstream_"); ok && b.config.IsValidUpstream(name) {
+		return "upstream"
+	}
+	if name, ok := strings.CutPrefix(cmd, "restart_sg_"); ok && b.config.IsValidUpstream(name) {
+		return "restart_sg"
+	}
+	return cmd
+}
+
+// commandRole returns the minimum role of a command (config overrides first)
+func (b *Bot) commandRole(cmd, args string) string {
+	if statusCommands[cmd] && strings.TrimSpace(args) == "" {
+		return config.RoleViewer
+	}
+	key := b.commandKey(cmd)
+	if role, ok := b.config.Telegram.Permissions["/"+key]; ok {
+		return role
+	}
+	if role, ok := commandRoles[key]; ok {
+		return role
+	}
+	return config.RoleViewer
+}
+
+// callbackRole returns the minimum role of a callback: "category:action" is
+// looked up before "category:*", config overrides before built-in roles
+func (b *Bot) callbackRole(category, action string) string {
+	keys := callbackKeys(category, action)
+	for _, key := range keys {
+		if role, ok := b.config.Telegram.Permissions[key]; ok {
+			return role
+		}
+	}
+	for _, key := range keys {
+		if role, ok := callbackRoles[key]; ok {
+			return role
+		}
+	}
+	return config.RoleViewer
+}
+
+// callbackKeys returns the lookup keys of a callback, most specific first
+func callbackKeys(category, action string) []string {
+	return []string{category + ":" + action, category + ":*"}
+}
+
+// userRole returns the role of a message or callback sender (nil: default role)
+func (b *Bot) userRole(user *tgbotapi.User) string {
+	if user == nil {
+		return b.config.Telegram.DefaultRole
+	}
+	return b.config.UserRole(user.ID)
+}
+
+// authorizeCommand reports whether the sender may run a command, replying if not
+func (b *Bot) authorizeCommand(msg *tgbotapi.Message, cmd, args string) bool {
+	required := b.commandRole(cmd, args)
+	role := b.userRole(msg.From)
+	if config.RoleAllows(role, required) {
+		return true
+	}
+
+	log.Printf("Denied /%s for user %d (role %s, requires %s)", cmd, userID(msg.From), role, required)
+	b.reply(msg.Chat.ID, fmt.Sprintf("⛔ /%s requires the %s role (yours: %s %s).\nUse /whoami to see your role.",
+		html.EscapeString(cmd), required, roleIcons[role], role))
+	return false
+}
+
+// authorizeCallback reports whether the sender may press a button, answering with a toast if not
+func (b *Bot) authorizeCallback(callback *tgbotapi.CallbackQuery, category, action string) bool {
+	required := b.callbackRole(category, action)
+	role := b.userRole(callback.From)
+	if config.RoleAllows(role, required) {
+		return true
+	}
+
+	log.Printf("Denied callback %s for user %d (role %s, requires %s)", callback.Data, userID(callback.From), role, required)
+	b.answerCallback(callback.ID, fmt.Sprintf("⛔ Requires %s role (yours: %s)", required, role))
+	return false
+}
+
+// handleWhoami handles /whoami
+func (b *Bot) handleWhoami(msg *tgbotapi.Message) {
+	if msg.From == nil {
+		b.reply(msg.Chat.ID, "❌ Unknown sender.")
+		return
+	}
+
+	role := b.userRole(msg.From)
+	name := strings.TrimSpace(msg.From.FirstName + " " + msg.From.LastName)
+
+	var sb strings.Builder
+	sb.WriteString("👤 <b>Who am I</b>\n\n")
+	sb.WriteString(fmt.Sprintf("Name: %s", html.EscapeString(name)))
+	if msg.From.UserName != "" {
+		sb.WriteString(fmt.Sprintf(" (@%s)", html.EscapeString(msg.From.UserName)))
+	}
+	sb.WriteString(fmt.Sprintf("\nID: <code>%d</code>\n", msg.From.ID))
+	sb.WriteString(fmt.Sprintf("Role: %s <b>%s</b>\n\n", roleIcons[role], role))
+	sb.WriteString(fmt.Sprintf("<i>%s</i>", roleDescriptions[role]))
+	b.reply(msg.Chat.ID, sb.String())
+}
+
+// userID returns the ID of a user, or 0 if unknown
+func userID(user *tgbotapi.User) int64 {
+	if user == nil {
+		return 0
+	}
+	return user.ID
+}
@@ -0,0 +1,155 @@
+package telegram
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/config"
+)
+
+// newAccessTestBot returns a bot with one upstream (home) and permission overrides
+func newAccessTestBot(permissions map[string]string) *Bot {
+	return &Bot{config: &config.Config{
+		Upstreams: map[string]*config.Upstream{"home": {IP: "10.0.0.2"}},
+		Telegram: config.TelegramConfig{
+			AdminUserIDs: []int64{1},
+			Users:        map[int64]string{2: config.RoleOperator, 3: config.RoleViewer},
+			DefaultRole:  config.RoleViewer,
+			Permissions:  permissions,
+		},
+	}}
+}
+
+func TestCommandRole(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions map[string]string
+		cmd, args   string
+		want        string
+	}{
+		{name: "unlisted command", cmd: "health", want: config.RoleViewer},
+		{name: "status without arguments", cmd: "edge", want: config.RoleViewer},
+		{name: "status with blank arguments", cmd: "vps", args: "  ", want: config.RoleViewer},
+		{name: "switch with arguments", cmd: "edge", args: "full", want: config.RoleOperator},
+		{name: "mode command", cmd: "vps_warp", want: config.RoleOperator},
+		{name: "dynamic upstream command", cmd: "upstream_home", want: config.RoleOperator},
+		{name: "dynamic restart command", cmd: "restart_sg_home", want: config.RoleOperator},
+		{name: "unknown upstream is not dynamic", cmd: "upstream_mars", want: config.RoleViewer},
+		{name: "admin command", cmd: "promql", args: "up", want: config.RoleAdmin},
+		{
+			name:        "override lowers role",
+			permissions: map[string]string{"/promql": config.RoleOperator},
+			cmd:         "promql", args: "up",
+			want: config.RoleOperator,
+		},
+		{
+			name:        "override applies to dynamic commands",
+			permissions: map[string]string{"/upstream": config.RoleAdmin},
+			cmd:         "upstream_home",
+			want:        config.RoleAdmin,
+		},
+		{
+			name:        "override raises unlisted command",
+			permissions: map[string]string{"/sla": config.RoleOperator},
+			cmd:         "sla",
+			want:        config.RoleOperator,
+		},
+		{
+			name:        "status without arguments ignores overrides",
+			permissions: map[string]string{"/edge": config.RoleAdmin},
+			cmd:         "edge",
+			want:        config.RoleViewer,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newAccessTestBot(tt.permissions)
+			if got := b.commandRole(tt.cmd, tt.args); got != tt.want {
+				t.Errorf("commandRole(%q, %q) = %s, want %s", tt.cmd, tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallbackRole(t *testing.T) {
+	tests := []struct {
+		name             string
+		permissions      map[string]string
+		category, action string
+		want             string
+	}{
+		{name: "unlisted category", category: "infra", action: "overview", want: config.RoleViewer},
+		{name: "category wildcard", category: "edge", action: "full", want: config.RoleOperator},
+		{name: "listed action", category: "silence", action: "expire", want: config.RoleOperator},
+		{name: "unlisted action of listed category", category: "silence", action: "list", want: config.RoleViewer},
+		{
+			name:        "override of one action",
+			permissions: map[string]string{"edge:full": config.RoleAdmin},
+			category:    "edge", action: "full",
+			want: config.RoleAdmin,
+		},
+		{
+			name:        "override of one action leaves others",
+			permissions: map[string]string{"edge:full": config.RoleAdmin},
+			category:    "edge", action: "split",
+			want: config.RoleOperator,
+		},
+		{
+			name:        "override wildcard",
+			permissions: map[string]string{"restart:*": config.RoleAdmin},
+			category:    "restart", action: "home",
+			want: config.RoleAdmin,
+		},
+		{
+			name:        "override action before override wildcard",
+			permissions: map[string]string{"vps:*": config.RoleAdmin, "vps:refresh": config.RoleViewer},
+			category:    "vps", action: "refresh",
+			want: config.RoleViewer,
+		},
+		{
+			name:        "override wildcard before built-in action",
+			permissions: map[string]string{"silence:*": config.RoleAdmin},
+			category:    "silence", action: "expire",
+			want: config.RoleAdmin,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newAccessTestBot(tt.permissions)
+			if got := b.callbackRole(tt.category, tt.action); got != tt.want {
+				t.Errorf("callbackRole(%q, %q) = %s, want %s", tt.category, tt.action, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserRoleAllows(t *testing.T) {
+	b := newAccessTestBot(nil)
+
+	tests := []struct {
+		name     string
+		user     *tgbotapi.User
+		required string
+		want     bool
+	}{
+		{name: "admin user runs admin command", user: &tgbotapi.User{ID: 1}, required: config.RoleAdmin, want: true},
+		{name: "operator runs operator command", user: &tgbotapi.User{ID: 2}, required: config.RoleOperator, want: true},
+		{name: "operator denied admin command", user: &tgbotapi.User{ID: 2}, required: config.RoleAdmin, want: false},
+		{name: "viewer runs viewer command", user: &tgbotapi.User{ID: 3}, required: config.RoleViewer, want: true},
+		{name: "viewer denied operator command", user: &tgbotapi.User{ID: 3}, required: config.RoleOperator, want: false},
+		{name: "unlisted user gets default role", user: &tgbotapi.User{ID: 99}, required: config.RoleOperator, want: false},
+		{name: "unknown sender gets default role", user: nil, required: config.RoleViewer, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role := b.userRole(tt.user)
+			if got := config.RoleAllows(role, tt.required); got != tt.want {
+				t.Errorf("RoleAllows(%s, %s) = %v, want %v", role, tt.required, got, tt.want)
+			}
+		})
+	}
+}
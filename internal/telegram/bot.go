@@ -9,8 +9,14 @@ import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 
 	"github.com/scinfra-pro/scinfra-bot/internal/config"
+	"github.com/scinfra-pro/scinfra-bot/internal/discovery"
 	"github.com/scinfra-pro/scinfra-bot/internal/edge"
 	"github.com/scinfra-pro/scinfra-bot/internal/health"
+	"github.com/scinfra-pro/scinfra-bot/internal/incident"
+	"github.com/scinfra-pro/scinfra-bot/internal/metrics"
+	"github.com/scinfra-pro/scinfra-bot/internal/silence"
+	"github.com/scinfra-pro/scinfra-bot/internal/sla"
+	"github.com/scinfra-pro/scinfra-bot/internal/store"
 	"github.com/scinfra-pro/scinfra-bot/internal/switchgate"
 )
 
@@ -28,11 +34,22 @@ type Bot struct {
 	edgeClient        *edge.Client
 	switchGateClients map[string]*switchgate.Client
 	healthChecker     *health.Checker
+	healthMonitor     *health.Monitor
+	discovery         *discovery.Discoverer
+	silences          *silence.Manager
+	incidents         *incident.Recorder
+	availability      *sla.Tracker
+
+	stop chan struct{} // closed by Stop, ends background jobs
 
 	// Cooldown tracking for callback spam protection
 	callbackCooldown map[int64]time.Time
 	cooldownMu       sync.Mutex
 
+	// Pending confirmations of risky buttons (key = token)
+	confirmations map[string]*confirmation
+	confirmMu     sync.Mutex
+
 	// IP caching for async updates
 	vpsIPCache  map[string]*ipCache // key = "upstream-mode" (e.g., "upstream1-warp")
 	edgeIPCache *ipCache            // edge-gateway IP cache
@@ -72,7 +89,10 @@ func New(cfg *config.Config, edgeClient *edge.Client) (*Bot, error) {
 	// Create health checker if infrastructure monitoring is enabled
 	var healthChecker *health.Checker
 	if cfg.IsInfrastructureEnabled() {
-		healthChecker = health.NewChecker(cfg, sgClients)
+		healthChecker, err = health.NewChecker(cfg, sgClients)
+		if err != nil {
+			return nil, fmt.Errorf("create health checker: %w", err)
+		}
 		// Set edge SSH stats provider
 		healthChecker.SetEdgeSSHStatsFunc(func() health.EdgeSSHStats {
 			stats := edgeClient.GetSSHStats()
@@ -87,17 +107,59 @@ func New(cfg *config.Config, edgeClient *edge.Client) (*Bot, error) {
 		log.Printf("Infrastructure monitoring enabled with %d clouds", len(cfg.Infrastructure.Clouds))
 	}
 
-	return &Bot{
+	// Attach services and servers discovered from Prometheus targets
+	// (before anything else reads the config concurrently)
+	var discoverer *discovery.Discoverer
+	if healthChecker != nil && cfg.Infrastructure.Discovery.Enabled {
+		discoverer = discovery.New(cfg, healthChecker)
+		result, err := discoverer.Apply()
+		if err != nil {
+			log.Printf("Warning: discovery failed: %v (using configured services)", err)
+		} else {
+			log.Printf("Discovery: %d services attached, %d servers created", result.Attached, result.Created)
+		}
+	}
+
+	b := &Bot{
 		api:               api,
 		config:            cfg,
 		edgeClient:        edgeClient,
 		switchGateClients: sgClients,
 		healthChecker:     healthChecker,
+		discovery:         discoverer,
 		callbackCooldown:  make(map[int64]time.Time),
+		confirmations:     make(map[string]*confirmation),
 		vpsIPCache:        make(map[string]*ipCache),
 		edgeIPCache:       &ipCache{},
 		ipCacheTTL:        60 * time.Second,
-	}, nil
+		stop:              make(chan struct{}),
+	}
+
+	// Silences, maintenance windows, incident history and availability samples
+	// (persisted in data dir)
+	if healthChecker != nil {
+		st, err := store.Open(cfg.Storage.DataDir)
+		if err != nil {
+			log.Printf("Warning: %v (state will not survive restarts)", err)
+			st = nil
+		}
+		b.silences = silence.NewManager(cfg, st)
+		b.incidents = incident.NewRecorder(st, cfg.Storage.IncidentRetention)
+		b.availability = sla.NewTracker(st, cfg.Storage.IncidentRetention)
+	}
+
+	// Create background health monitor (notifications go to all allowed chats
+	// if alerts are enabled; incidents and availability are recorded anyway)
+	if healthChecker != nil {
+		b.healthMonitor = health.NewMonitor(healthChecker, b, cfg.Infrastructure.Alerts)
+		b.healthMonitor.SetSilencer(b.silences)
+		b.healthMonitor.SetRecorder(b.incidents)
+		b.healthMonitor.SetSampler(b.availability)
+	}
+
+	metrics.Default.RegisterCollector(b.collectMetrics)
+
+	return b, nil
 }
 
 // getSwitchGateClient returns switch-gate client for upstream name
@@ -115,6 +177,16 @@ func (b *Bot) Start() error {
 
 	updates := b.api.GetUpdatesChan(u)
 
+	// Start background health monitor
+	if b.healthMonitor != nil {
+		b.healthMonitor.Start()
+	}
+
+	// Start scheduled availability report
+	if b.healthMonitor != nil && b.config.Infrastructure.SLAReport.Enabled {
+		go b.runSLAReports()
+	}
+
 	log.Println("Bot started, waiting for messages...")
 
 	for update := range updates {
@@ -149,6 +221,10 @@ func (b *Bot) Start() error {
 
 // Stop gracefully stops the bot
 func (b *Bot) Stop() {
+	close(b.stop)
+	if b.healthMonitor != nil {
+		b.healthMonitor.Stop()
+	}
 	b.api.StopReceivingUpdates()
 }
 
@@ -158,6 +234,7 @@ func (b *Bot) reply(chatID int64, text string) {
 	msg.ParseMode = "HTML"
 	if _, err := b.api.Send(msg); err != nil {
 		log.Printf("Failed to send message: %v", err)
+		metrics.TelegramErrorsTotal.Inc("send")
 	}
 }
 
@@ -168,6 +245,17 @@ func (b *Bot) replyWithKeyboard(chatID int64, text string, keyboard tgbotapi.Inl
 	msg.ReplyMarkup = keyboard
 	if _, err := b.api.Send(msg); err != nil {
 		log.Printf("Failed to send message with keyboard: %v", err)
+		metrics.TelegramErrorsTotal.Inc("send")
+	}
+}
+
+// editMessage edits existing message with new text and removes its keyboard
+func (b *Bot) editMessage(chatID int64, messageID int, text string) {
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	edit.ParseMode = "HTML"
+	if _, err := b.api.Send(edit); err != nil {
+		log.Printf("Failed to edit message: %v", err)
+		metrics.TelegramErrorsTotal.Inc("edit")
 	}
 }
 
@@ -178,6 +266,28 @@ func (b *Bot) editMessageWithKeyboard(chatID int64, messageID int, text string,
 	edit.ReplyMarkup = &keyboard
 	if _, err := b.api.Send(edit); err != nil {
 		log.Printf("Failed to edit message: %v", err)
+		metrics.TelegramErrorsTotal.Inc("edit")
+	}
+}
+
+// sendDocument sends a file to the chat
+func (b *Bot) sendDocument(chatID int64, name string, data []byte, caption string) {
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: name, Bytes: data})
+	doc.Caption = caption
+	if _, err := b.api.Send(doc); err != nil {
+		log.Printf("Failed to send document: %v", err)
+		metrics.TelegramErrorsTotal.Inc("document")
+	}
+}
+
+// sendPhoto sends an image to the chat with an HTML caption
+func (b *Bot) sendPhoto(chatID int64, name string, data []byte, caption string) {
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: name, Bytes: data})
+	photo.Caption = caption
+	photo.ParseMode = "HTML"
+	if _, err := b.api.Send(photo); err != nil {
+		log.Printf("Failed to send photo: %v", err)
+		metrics.TelegramErrorsTotal.Inc("photo")
 	}
 }
 
@@ -186,6 +296,7 @@ func (b *Bot) answerCallback(callbackID string, text string) {
 	callback := tgbotapi.NewCallback(callbackID, text)
 	if _, err := b.api.Request(callback); err != nil {
 		log.Printf("Failed to answer callback: %v", err)
+		metrics.TelegramErrorsTotal.Inc("callback")
 	}
 }
 
@@ -197,6 +308,7 @@ func (b *Bot) SendNotification(text string) error {
 		msg.ParseMode = "HTML"
 		if _, err := b.api.Send(msg); err != nil {
 			log.Printf("Failed to send notification to chat %d: %v", chatID, err)
+			metrics.TelegramErrorsTotal.Inc("notification")
 			lastErr = err
 		}
 	}
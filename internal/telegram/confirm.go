@@ -0,0 +1,212 @@
+package telegram
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// confirmation is a pending two-step confirmation of a button press
+// It is stored server-side and referenced by a random token in the callback data,
+// so replayed or stale confirm buttons are rejected
+type confirmation struct {
+	data      string // callback data of the confirmed action
+	userID    int64  // only this user may confirm
+	chatID    int64
+	messageID int
+	expires   time.Time
+}
+
+// Errors of confirmation tokens (shown as toasts)
+var (
+	errConfirmInvalid = errors.New("confirmation is no longer valid")
+	errConfirmExpired = errors.New("confirmation expired")
+	errConfirmUser    = errors.New("only the user who pressed the button can confirm")
+)
+
+// needsConfirmation reports whether a callback is one of telegram.confirm.actions
+func (b *Bot) needsConfirmation(category, action string) bool {
+	if category == "confirm" {
+		return false
+	}
+	for _, key := range callbackKeys(category, action) {
+		if slices.Contains(b.config.Telegram.Confirm.Actions, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// askConfirmation stores a confirmation for a button press and edits the
+// message into a confirm prompt
+func (b *Bot) askConfirmation(callback *tgbotapi.CallbackQuery) {
+	token, err := newConfirmToken()
+	if err != nil {
+		log.Printf("Failed to create confirmation token: %v", err)
+		b.answerCallback(callback.ID, "❌ Error: "+err.Error())
+		return
+	}
+
+	chatID := callback.Message.Chat.ID
+	messageID := callback.Message.MessageID
+	timeout := b.config.Telegram.Confirm.Timeout
+	now := time.Now()
+
+	b.confirmMu.Lock()
+	for t, c := range b.confirmations {
+		// Drop expired confirmations and older prompts of the same message
+		if now.After(c.expires) || (c.chatID == chatID && c.messageID == messageID) {
+			delete(b.confirmations, t)
+		}
+	}
+	b.confirmations[token] = &confirmation{
+		data:      callback.Data,
+		userID:    userID(callback.From),
+		chatID:    chatID,
+		messageID: messageID,
+		expires:   now.Add(timeout),
+	}
+	b.confirmMu.Unlock()
+
+	name := "you"
+	if callback.From != nil {
+		name = html.EscapeString(callback.From.FirstName)
+	}
+	text := fmt.Sprintf("⚠️ <b>Confirm</b> %s?\n\n<i>Only %s can confirm, within %s.</i>",
+		b.describeAction(callback.Data), name, timeout)
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("✅ Confirm", "confirm:yes:"+token),
+		tgbotapi.NewInlineKeyboardButtonData("❌ Cancel", "confirm:no:"+token),
+	))
+	b.editMessageWithKeyboard(chatID, messageID, text, keyboard)
+	b.answerCallback(callback.ID, "⚠️ Confirm?")
+}
+
+// handleConfirmCallback handles confirm prompt buttons (confirm:yes:<token>, confirm:no:<token>)
+func (b *Bot) handleConfirmCallback(callback *tgbotapi.CallbackQuery, parts []string) {
+	if len(parts) < 3 {
+		b.answerCallback(callback.ID, "❌ Invalid callback")
+		return
+	}
+
+	pending, err := b.takeConfirmation(parts[2], callback)
+	switch {
+	case errors.Is(err, errConfirmExpired):
+		b.showConfirmReturnView(callback.Message, pending.data)
+		b.answerCallback(callback.ID, "⌛ "+err.Error())
+		return
+	case err != nil:
+		b.answerCallback(callback.ID, "❌ "+err.Error())
+		return
+	}
+
+	if parts[1] != "yes" {
+		b.showConfirmReturnView(callback.Message, pending.data)
+		b.answerCallback(callback.ID, "✖️ Cancelled")
+		return
+	}
+
+	log.Printf("Confirmed %s by user %d", pending.data, pending.userID)
+
+	// Run the original button press on the same message
+	confirmed := *callback
+	confirmed.Data = pending.data
+	actionParts := strings.Split(pending.data, ":")
+	if !b.authorizeCallback(&confirmed, actionParts[0], actionParts[1]) {
+		return
+	}
+	b.dispatchCallback(&confirmed, actionParts)
+}
+
+// takeConfirmation removes and returns the confirmation of a token
+// Presses by other users leave the confirmation in place
+// Expired confirmations are returned with errConfirmExpired
+func (b *Bot) takeConfirmation(token string, callback *tgbotapi.CallbackQuery) (*confirmation, error) {
+	b.confirmMu.Lock()
+	defer b.confirmMu.Unlock()
+
+	c, ok := b.confirmations[token]
+	if !ok || c.chatID != callback.Message.Chat.ID || c.messageID != callback.Message.MessageID {
+		return nil, errConfirmInvalid
+	}
+	if c.userID != userID(callback.From) {
+		return nil, errConfirmUser
+	}
+	delete(b.confirmations, token)
+	if time.Now().After(c.expires) {
+		return c, errConfirmExpired
+	}
+	return c, nil
+}
+
+// describeAction describes the action of callback data for a confirm prompt
+func (b *Bot) describeAction(data string) string {
+	parts := strings.Split(data, ":")
+	arg := ""
+	if len(parts) > 1 {
+		arg = html.EscapeString(parts[1])
+	}
+
+	switch {
+	case parts[0] == "edge":
+		return fmt.Sprintf("switch edge-gateway to <b>%s</b>", arg)
+	case parts[0] == "upstream":
+		return fmt.Sprintf("switch upstream to <b>%s</b>", html.EscapeString(b.config.GetUpstreamDisplayName(parts[1])))
+	case parts[0] == "vps":
+		return fmt.Sprintf("switch VPS to <b>%s</b>", arg)
+	case parts[0] == "restart" && len(parts) > 2:
+		return fmt.Sprintf("restart switch-gate on <b>%s</b>", html.EscapeString(b.config.GetUpstreamDisplayName(parts[2])))
+	case parts[0] == "silence" && len(parts) > 2:
+		return fmt.Sprintf("%s silence <b>#%s</b>", arg, html.EscapeString(parts[2]))
+	default:
+		return fmt.Sprintf("<code>%s</code>", html.EscapeString(data))
+	}
+}
+
+// showConfirmReturnView edits a cancelled or expired confirm prompt back into
+// the view the button was pressed in (or a plain note without buttons)
+func (b *Bot) showConfirmReturnView(msg *tgbotapi.Message, data string) {
+	text, keyboard, ok := b.confirmReturnView(data)
+	if !ok {
+		b.editMessage(msg.Chat.ID, msg.MessageID, "✖️ Cancelled")
+		return
+	}
+	b.editMessageWithKeyboard(msg.Chat.ID, msg.MessageID, text, keyboard)
+}
+
+// confirmReturnView returns the view a confirm prompt goes back to when
+// cancelled or expired: the view the button was pressed in
+// ok is false if the view is unknown
+func (b *Bot) confirmReturnView(data string) (text string, keyboard tgbotapi.InlineKeyboardMarkup, ok bool) {
+	category, _, _ := strings.Cut(data, ":")
+	switch category {
+	case "edge", "upstream", "vps":
+		text, keyboard = b.buildStatusMessage()
+		return text, keyboard, true
+	case "restart":
+		return restartMenuText, b.buildRestartKeyboard(), true
+	case "silence":
+		if b.silences != nil {
+			text, keyboard = b.buildMaintenanceMessage()
+			return text, keyboard, true
+		}
+	}
+	return "", tgbotapi.InlineKeyboardMarkup{}, false
+}
+
+// newConfirmToken returns a random confirmation token (16 hex characters)
+func newConfirmToken() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("read random: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
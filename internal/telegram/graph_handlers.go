@@ -0,0 +1,268 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/chart"
+	"github.com/scinfra-pro/scinfra-bot/internal/health"
+	"github.com/scinfra-pro/scinfra-bot/internal/prometheus"
+)
+
+// graphDefaultRange is used by /graph without a range
+const graphDefaultRange = "24h"
+
+// graphRange is a /graph time range and the resolution of its queries
+type graphRange struct {
+	duration time.Duration
+	step     time.Duration // query resolution, also the rate() window (at least health.DefaultRateWindow)
+}
+
+// graphRanges are the supported /graph time ranges
+var graphRanges = map[string]graphRange{
+	"1h":  {duration: time.Hour, step: 30 * time.Second},
+	"24h": {duration: 24 * time.Hour, step: 5 * time.Minute},
+	"7d":  {duration: 7 * 24 * time.Hour, step: 30 * time.Minute},
+}
+
+// graphQuery is one query of a chart, built from the server's exporter templates
+type graphQuery struct {
+	name    string // series name (empty: named by health.FilesystemLabels, or a single unnamed series)
+	metric  string // exporter metric
+	totalOf string // if set, the query is 100 * metric / totalOf
+}
+
+// graphResource describes a /graph resource
+type graphResource struct {
+	label    string // button and title label
+	kind     chart.Kind
+	yMax     float64 // fixed upper bound (0: from the data)
+	format   func(float64) string
+	queries  []graphQuery
+	fallback []graphQuery // used if the exporter lacks a metric of queries
+}
+
+// graphResourceOrder is the order of resource buttons in server details
+var graphResourceOrder = []string{"cpu", "mem", "disk", "net"}
+
+// graphResources are the charts available for Prometheus-monitored servers
+var graphResources = map[string]graphResource{
+	"cpu": {
+		label:   "CPU",
+		kind:    chart.KindArea,
+		yMax:    100,
+		format:  formatPercentTick,
+		queries: []graphQuery{{metric: health.MetricCPU}},
+	},
+	"mem": {
+		label:   "RAM",
+		kind:    chart.KindArea,
+		yMax:    100,
+		format:  formatPercentTick,
+		queries: []graphQuery{{metric: health.MetricMemUsed, totalOf: health.MetricMemTotal}},
+	},
+	"disk": {
+		label:    "Disk",
+		kind:     chart.KindLine,
+		yMax:     100,
+		format:   formatPercentTick,
+		queries:  []graphQuery{{metric: health.MetricFilesystems}},
+		fallback: []graphQuery{{name: "/", metric: health.MetricDiskUsed, totalOf: health.MetricDiskSize}},
+	},
+	"net": {
+		label:  "Net",
+		kind:   chart.KindLine,
+		format: formatBitrate,
+		queries: []graphQuery{
+			{name: "rx", metric: health.MetricNetRx},
+			{name: "tx", metric: health.MetricNetTx},
+		},
+	},
+}
+
+// graphExpr returns the PromQL of a chart query, or false if the exporter
+// has no query for one of its metrics
+func graphExpr(q graphQuery, target *health.PrometheusTarget, window time.Duration) (string, bool) {
+	expr, ok := target.Exporter.Query(q.metric, target.Instance, window)
+	if !ok || q.totalOf == "" {
+		return expr, ok
+	}
+	total, ok := target.Exporter.Query(q.totalOf, target.Instance, window)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("100 * (%s) / (%s)", expr, total), true
+}
+
+// graphExprs returns the PromQL of the resource queries, or of the fallback
+// queries if the exporter lacks a metric
+func graphExprs(res graphResource, target *health.PrometheusTarget, window time.Duration) ([]graphQuery, []string, bool) {
+	for _, queries := range [][]graphQuery{res.queries, res.fallback} {
+		var exprs []string
+		for _, q := range queries {
+			expr, ok := graphExpr(q, target, window)
+			if !ok {
+				break
+			}
+			exprs = append(exprs, expr)
+		}
+		if len(queries) > 0 && len(exprs) == len(queries) {
+			return queries, exprs, true
+		}
+	}
+	return nil, nil, false
+}
+
+// graphSeriesName returns the chart series name of a result
+func graphSeriesName(q graphQuery, metric map[string]string) string {
+	if q.name != "" {
+		return q.name
+	}
+	for _, label := range health.FilesystemLabels {
+		if v := metric[label]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// formatPercentTick formats a value axis label in percent
+func formatPercentTick(v float64) string {
+	return fmt.Sprintf("%.0f%%", v)
+}
+
+// graphUsage is the /graph usage message
+const graphUsage = "📉 <b>Usage:</b> <code>/graph &lt;server&gt; &lt;cpu|mem|disk|net&gt; [1h|24h|7d]</code>"
+
+// handleGraph handles /graph <server> <cpu|mem|disk|net> [1h|24h|7d]
+func (b *Bot) handleGraph(msg *tgbotapi.Message, args string) {
+	if b.healthChecker == nil {
+		b.reply(msg.Chat.ID, "❌ Infrastructure monitoring is not enabled.")
+		return
+	}
+
+	fields := strings.Fields(args)
+	if len(fields) < 2 || len(fields) > 3 {
+		b.reply(msg.Chat.ID, graphUsage)
+		return
+	}
+
+	server := b.findServer(fields[0])
+	if server == nil {
+		b.reply(msg.Chat.ID, fmt.Sprintf("❌ Unknown server: <code>%s</code>", html.EscapeString(fields[0])))
+		return
+	}
+	resource := strings.ToLower(fields[1])
+	if _, ok := graphResources[resource]; !ok {
+		b.reply(msg.Chat.ID, graphUsage)
+		return
+	}
+	rangeName := graphDefaultRange
+	if len(fields) == 3 {
+		rangeName = strings.ToLower(fields[2])
+		if _, ok := graphRanges[rangeName]; !ok {
+			b.reply(msg.Chat.ID, graphUsage)
+			return
+		}
+	}
+
+	b.sendGraph(msg.Chat.ID, server.ID, resource, rangeName)
+}
+
+// handleGraphCallback handles chart buttons (format: graph:serverID:resource:range)
+func (b *Bot) handleGraphCallback(callback *tgbotapi.CallbackQuery, parts []string) {
+	if len(parts) < 4 || b.healthChecker == nil {
+		b.answerCallback(callback.ID, "❌ Invalid callback")
+		return
+	}
+	serverID, resource, rangeName := parts[1], parts[2], parts[3]
+	if _, ok := graphResources[resource]; !ok {
+		b.answerCallback(callback.ID, "❌ Unknown resource")
+		return
+	}
+	if _, ok := graphRanges[rangeName]; !ok {
+		b.answerCallback(callback.ID, "❌ Unknown range")
+		return
+	}
+
+	b.answerCallback(callback.ID, "📈 Rendering chart...")
+	b.sendGraph(callback.Message.Chat.ID, serverID, resource, rangeName)
+}
+
+// sendGraph renders a resource chart and sends it as a photo
+func (b *Bot) sendGraph(chatID int64, serverID, resource, rangeName string) {
+	data, caption, err := b.renderGraph(serverID, resource, rangeName, time.Now())
+	if err != nil {
+		log.Printf("Graph %s/%s/%s failed: %v", serverID, resource, rangeName, err)
+		b.reply(chatID, fmt.Sprintf("❌ %s", html.EscapeString(err.Error())))
+		return
+	}
+	b.sendPhoto(chatID, fmt.Sprintf("%s-%s-%s.png", serverID, resource, rangeName), data, caption)
+}
+
+// renderGraph queries the resource history of a server and renders the chart
+// Returns the PNG image and the photo caption
+func (b *Bot) renderGraph(serverID, resource, rangeName string, now time.Time) ([]byte, string, error) {
+	target, err := b.healthChecker.PrometheusTarget(serverID)
+	if err != nil {
+		return nil, "", err
+	}
+	server := b.config.GetServer(serverID)
+	res := graphResources[resource]
+	rng := graphRanges[rangeName]
+
+	start := now.Add(-rng.duration)
+	window := max(rng.step, health.DefaultRateWindow)
+	queries, exprs, ok := graphExprs(res, target, window)
+	if !ok {
+		return nil, "", fmt.Errorf("%s exporter has no %s metrics", target.Exporter.Name, res.label)
+	}
+
+	c := &chart.Chart{
+		Title:       fmt.Sprintf("%s - %s, last %s", server.Name, res.label, rangeName),
+		Kind:        res.kind,
+		Start:       start,
+		End:         now,
+		Step:        rng.step,
+		YMax:        res.yMax,
+		FormatValue: res.format,
+	}
+	for i, q := range queries {
+		results, err := target.Client.QueryRange(exprs[i], start, now, rng.step)
+		if err != nil {
+			return nil, "", fmt.Errorf("query %s: %w", res.label, err)
+		}
+		sort.Slice(results, func(i, j int) bool {
+			return graphSeriesName(q, results[i].Metric) < graphSeriesName(q, results[j].Metric)
+		})
+		for _, r := range results {
+			c.Series = append(c.Series, chart.Series{Name: graphSeriesName(q, r.Metric), Points: chartPoints(r.Points)})
+		}
+	}
+
+	data, err := c.Render()
+	if err == chart.ErrNoData {
+		return nil, "", fmt.Errorf("no %s data for %s in the last %s", res.label, server.Name, rangeName)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	caption := fmt.Sprintf("📈 %s <b>%s</b> — %s, last %s", server.Icon, html.EscapeString(server.Name), res.label, rangeName)
+	return data, caption, nil
+}
+
+// chartPoints converts Prometheus samples to chart points
+func chartPoints(points []prometheus.Point) []chart.Point {
+	out := make([]chart.Point, len(points))
+	for i, p := range points {
+		out[i] = chart.Point{Time: p.Time, Value: p.Value}
+	}
+	return out
+}
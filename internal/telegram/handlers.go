@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/metrics"
 )
 
 // capitalize returns string with first letter uppercased
@@ -23,6 +25,16 @@ func (b *Bot) handleCommand(msg *tgbotapi.Message) {
 
 	log.Printf("Command: /%s %s (from chat %d)", cmd, args, msg.Chat.ID)
 
+	// Denied and unknown commands are counted as "denied" and "unknown", and
+	// dynamic commands by their shared key, to bound label cardinality
+	label := "denied"
+	defer func() { metrics.CommandsTotal.Inc(label) }()
+
+	if !b.authorizeCommand(msg, cmd, args) {
+		return
+	}
+	label = b.commandKey(cmd)
+
 	// Dynamic upstream commands: /upstream_<name>
 	if strings.HasPrefix(cmd, "upstream_") {
 		name := strings.TrimPrefix(cmd, "upstream_")
@@ -76,9 +88,30 @@ func (b *Bot) handleCommand(msg *tgbotapi.Message) {
 		b.handleInfra(msg)
 	case "health":
 		b.handleHealth(msg)
+	case "silence":
+		b.handleSilence(msg, args)
+	case "maintenance":
+		b.handleMaintenance(msg)
+	case "incidents":
+		b.handleIncidents(msg, args)
+	case "sla":
+		b.handleSLA(msg, args)
+	case "graph":
+		b.handleGraph(msg, args)
+	case "alerts":
+		b.handleAlerts(msg)
+	case "targets":
+		b.handleTargets(msg)
+	case "promql":
+		b.handlePromQL(msg, args)
+	case "q":
+		b.handleSavedQuery(msg, args)
 	case "diag":
 		b.handleDiag(msg)
+	case "whoami":
+		b.handleWhoami(msg)
 	default:
+		label = "unknown"
 		b.reply(msg.Chat.ID, fmt.Sprintf("Unknown command: /%s\nUse /help for available commands.", cmd))
 	}
 }
@@ -104,6 +137,7 @@ func (b *Bot) handleHelp(msg *tgbotapi.Message) {
 	sb.WriteString("ℹ️ /status - Full VPN status (with inline buttons)\n")
 	sb.WriteString("ℹ️ /ip - Current external IP\n")
 	sb.WriteString("📊 /traffic - Traffic statistics\n")
+	sb.WriteString("👤 /whoami - Your user ID and role\n")
 	sb.WriteString("ℹ️ /help - This message\n")
 
 	// Edge-gateway commands
@@ -133,11 +167,22 @@ func (b *Bot) handleHelp(msg *tgbotapi.Message) {
 		sb.WriteString("\n<b>Infrastructure:</b>\n")
 		sb.WriteString("🏗️ /infra - Infrastructure overview\n")
 		sb.WriteString("📊 /health - Health status (with metrics)\n")
+		sb.WriteString("🔕 /silence - Silence alerts for a server, service or cloud\n")
+		sb.WriteString("🗓️ /maintenance - Active silences and maintenance windows\n")
+		sb.WriteString("📜 /incidents - Incident history ([server] [Nd])\n")
+		sb.WriteString("📈 /sla - Availability report ([day|week|month] [csv])\n")
+		sb.WriteString("📉 /graph - Resource chart (server cpu|mem|disk|net [1h|24h|7d])\n")
+		sb.WriteString("🚨 /alerts - Firing and pending Prometheus alerts\n")
+		sb.WriteString("🎯 /targets - Prometheus scrape targets that are down\n")
+		sb.WriteString("🔎 /q - Saved Prometheus queries ([name])\n")
 	}
 
 	// Dynamic admin commands
 	sb.WriteString("\n<b>Admin:</b>\n")
 	sb.WriteString("🔍 /diag - Diagnostics (test VPS connections)\n")
+	if b.config.IsInfrastructureEnabled() {
+		sb.WriteString("🔎 /promql - Run a PromQL query ([@datasource] expr)\n")
+	}
 	sb.WriteString("🔄 /restart - Restart services menu\n")
 	sb.WriteString("🔁 /restart_sg - Restart switch-gate (current upstream)\n")
 	for _, name := range b.config.GetUpstreamNames() {
@@ -158,6 +203,7 @@ func (b *Bot) handleStatus(msg *tgbotapi.Message) {
 	sent, err := b.api.Send(sentMsg)
 	if err != nil {
 		log.Printf("Failed to send status message: %v", err)
+		metrics.TelegramErrorsTotal.Inc("send")
 		return
 	}
 
@@ -598,7 +644,32 @@ func (b *Bot) handleCallback(callback *tgbotapi.CallbackQuery) {
 
 	log.Printf("Callback: %s (from chat %d)", data, callback.Message.Chat.ID)
 
-	switch category {
+	// Denied and unknown callbacks are counted as "denied" and "unknown"
+	// to bound label cardinality
+	label := "denied"
+	defer func() { metrics.CallbacksTotal.Inc(label) }()
+
+	if !b.authorizeCallback(callback, category, value) {
+		return
+	}
+	label = category
+	if b.needsConfirmation(category, value) {
+		b.askConfirmation(callback)
+		return
+	}
+
+	if !b.dispatchCallback(callback, parts) {
+		label = "unknown"
+		b.answerCallback(callback.ID, "❌ Unknown action")
+	}
+}
+
+// dispatchCallback routes an authorized callback to its category handler
+// Returns false for unknown categories
+func (b *Bot) dispatchCallback(callback *tgbotapi.CallbackQuery, parts []string) bool {
+	value := parts[1]
+
+	switch parts[0] {
 	case "edge":
 		b.handleEdgeCallback(callback, value)
 	case "upstream":
@@ -611,9 +682,22 @@ func (b *Bot) handleCallback(callback *tgbotapi.CallbackQuery) {
 		b.handleRestartCallback(callback, parts)
 	case "infra":
 		b.handleInfraCallback(callback, parts)
+	case "silence":
+		b.handleSilenceCallback(callback, parts)
+	case "incidents":
+		b.handleIncidentsCallback(callback, parts)
+	case "sla":
+		b.handleSLACallback(callback, parts)
+	case "graph":
+		b.handleGraphCallback(callback, parts)
+	case "prom":
+		b.handlePromCallback(callback, parts)
+	case "confirm":
+		b.handleConfirmCallback(callback, parts)
 	default:
-		b.answerCallback(callback.ID, "❌ Unknown action")
+		return false
 	}
+	return true
 }
 
 // handleEdgeCallback handles edge mode button press
@@ -794,15 +878,16 @@ func (b *Bot) buildTrafficMessage() (string, tgbotapi.InlineKeyboardMarkup) {
 	return sb.String(), keyboard
 }
 
+// restartMenuText is the text of the restart menu
+const restartMenuText = "🔄 <b>Restart</b>\n\nSelect service to restart:"
+
 // handleRestart handles the /restart command
 func (b *Bot) handleRestart(msg *tgbotapi.Message, args string) {
 	args = strings.TrimSpace(args)
 
 	// No args — show menu
 	if args == "" {
-		text := "🔄 <b>Restart</b>\n\nSelect service to restart:"
-		keyboard := b.buildRestartKeyboard()
-		b.replyWithKeyboard(msg.Chat.ID, text, keyboard)
+		b.replyWithKeyboard(msg.Chat.ID, restartMenuText, b.buildRestartKeyboard())
 		return
 	}
 
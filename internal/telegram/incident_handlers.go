@@ -0,0 +1,287 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/config"
+	"github.com/scinfra-pro/scinfra-bot/internal/health"
+	"github.com/scinfra-pro/scinfra-bot/internal/incident"
+)
+
+// Incident list settings
+const (
+	incidentsPageSize    = 8
+	incidentsDefaultDays = 7
+	incidentsMaxDays     = 3650 // keeps callback data short
+)
+
+// handleIncidents handles /incidents [server] [Nd]
+func (b *Bot) handleIncidents(msg *tgbotapi.Message, args string) {
+	if b.incidents == nil {
+		b.reply(msg.Chat.ID, "❌ Infrastructure monitoring is not enabled.")
+		return
+	}
+
+	serverID := ""
+	days := incidentsDefaultDays
+	for _, field := range strings.Fields(args) {
+		// Days need the "d" suffix, so all-digit server IDs stay selectable
+		if n, err := strconv.Atoi(strings.TrimSuffix(field, "d")); err == nil && n > 0 && strings.HasSuffix(field, "d") {
+			days = min(n, incidentsMaxDays)
+			continue
+		}
+		server := b.findServer(field)
+		if server == nil {
+			b.reply(msg.Chat.ID, fmt.Sprintf("❌ Unknown server: %s\n\n"+
+				"<b>Usage:</b> <code>/incidents [server] [Nd]</code>", html.EscapeString(field)))
+			return
+		}
+		serverID = server.ID
+	}
+
+	text, keyboard := b.buildIncidentsMessage(serverID, days, 0)
+	b.replyWithKeyboard(msg.Chat.ID, text, keyboard)
+}
+
+// findServer finds a server by ID or display name (case-insensitive)
+func (b *Bot) findServer(name string) *config.ServerConfig {
+	if server := b.config.GetServer(name); server != nil {
+		return server
+	}
+	for i := range b.config.Infrastructure.Clouds {
+		cloud := &b.config.Infrastructure.Clouds[i]
+		for j := range cloud.Servers {
+			if strings.EqualFold(cloud.Servers[j].ID, name) || strings.EqualFold(cloud.Servers[j].Name, name) {
+				return &cloud.Servers[j]
+			}
+		}
+	}
+	return nil
+}
+
+// buildIncidentsMessage builds one page of the incident timeline
+func (b *Bot) buildIncidentsMessage(serverID string, days, page int) (string, tgbotapi.InlineKeyboardMarkup) {
+	now := time.Now()
+	list := b.incidents.List(serverID, now.AddDate(0, 0, -days))
+
+	pages := (len(list) + incidentsPageSize - 1) / incidentsPageSize
+	if page >= pages {
+		page = pages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+
+	var sb strings.Builder
+	scope := "all servers"
+	if serverID != "" {
+		scope = html.EscapeString(serverID)
+	}
+	sb.WriteString(fmt.Sprintf("📜 <b>Incidents</b> — %s, last %d days\n", scope, days))
+
+	if len(list) == 0 {
+		sb.WriteString("\nNo incidents. 🎉")
+	}
+
+	start := page * incidentsPageSize
+	end := min(start+incidentsPageSize, len(list))
+	var buttons []tgbotapi.InlineKeyboardButton
+	for _, inc := range list[start:end] {
+		sb.WriteString(fmt.Sprintf("\n%s <b>#%d</b> %s\n", incidentIcon(&inc), inc.ID, html.EscapeString(inc.Name)))
+		status := "closed"
+		if inc.Ongoing() {
+			status = "ongoing"
+		}
+		sb.WriteString(fmt.Sprintf("     %s · %s %s\n",
+			inc.OpenedAt.Format("Jan 2 15:04"), health.FormatDuration(inc.Duration(now)), status))
+		if len(inc.Reasons) > 0 {
+			sb.WriteString(fmt.Sprintf("     └ %s\n", html.EscapeString(inc.Reasons[0])))
+		}
+
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(
+			fmt.Sprintf("#%d", inc.ID), fmt.Sprintf("incidents:show:%d:%s", inc.ID, b.incidentsListRef(serverID, days, page))))
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i := 0; i < len(buttons); i += 4 {
+		rows = append(rows, buttons[i:min(i+4, len(buttons))])
+	}
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("◀ Prev",
+			"incidents:page:"+b.incidentsListRef(serverID, days, page-1)))
+	}
+	if pages > 1 {
+		sb.WriteString(fmt.Sprintf("\n<i>Page %d/%d</i>", page+1, pages))
+	}
+	if page < pages-1 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("Next ▶",
+			"incidents:page:"+b.incidentsListRef(serverID, days, page+1)))
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔄 Refresh", "incidents:page:"+b.incidentsListRef(serverID, days, page)),
+	))
+
+	return sb.String(), tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// incidentsListRef encodes list position as "server:days:page" for callback data
+// The server is its index in the config ("-" stands for all servers), so the
+// data stays within Telegram's 64 bytes for any server ID
+func (b *Bot) incidentsListRef(serverID string, days, page int) string {
+	ref := "-"
+	for i, server := range b.config.GetAllServers() {
+		if server.ID == serverID {
+			ref = strconv.Itoa(i)
+			break
+		}
+	}
+	return fmt.Sprintf("%s:%d:%d", ref, days, page)
+}
+
+// parseIncidentsListRef decodes "server:days:page" from callback parts
+func (b *Bot) parseIncidentsListRef(parts []string) (serverID string, days, page int, ok bool) {
+	if len(parts) < 3 {
+		return "", 0, 0, false
+	}
+	days, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, 0, false
+	}
+	page, err = strconv.Atoi(parts[2])
+	if err != nil {
+		return "", 0, 0, false
+	}
+	if parts[0] != "-" {
+		servers := b.config.GetAllServers()
+		i, err := strconv.Atoi(parts[0])
+		if err != nil || i < 0 || i >= len(servers) {
+			return "", 0, 0, false
+		}
+		serverID = servers[i].ID
+	}
+	return serverID, days, page, true
+}
+
+// buildIncidentDetailMessage builds the detail view of one incident
+func (b *Bot) buildIncidentDetailMessage(id int, listRef string) (string, tgbotapi.InlineKeyboardMarkup) {
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("← Back", "incidents:page:"+listRef),
+	))
+
+	inc, ok := b.incidents.Get(id)
+	if !ok {
+		return fmt.Sprintf("❌ Incident #%d not found", id), keyboard
+	}
+
+	now := time.Now()
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s <b>Incident #%d</b> — %s\n\n", incidentIcon(&inc), inc.ID, html.EscapeString(inc.Name)))
+	sb.WriteString(fmt.Sprintf("Opened: %s\n", inc.OpenedAt.Format("Jan 2 15:04:05")))
+	if inc.Ongoing() {
+		sb.WriteString(fmt.Sprintf("Status: ongoing (%s)\n", health.FormatDuration(inc.Duration(now))))
+	} else {
+		sb.WriteString(fmt.Sprintf("Closed: %s (%s)\n", inc.ClosedAt.Format("Jan 2 15:04:05"), health.FormatDuration(inc.Duration(now))))
+	}
+	sb.WriteString(fmt.Sprintf("Worst level: %s\n", inc.Level))
+
+	if len(inc.Reasons) > 0 {
+		sb.WriteString("\n<b>Reasons:</b>\n")
+		for _, reason := range inc.Reasons {
+			sb.WriteString(fmt.Sprintf("  • %s\n", html.EscapeString(reason)))
+		}
+	}
+
+	if len(inc.Events) > 0 {
+		sb.WriteString("\n<b>Webhook events:</b>\n")
+		for _, ev := range inc.Events {
+			line := fmt.Sprintf("  • %s %s <code>%s</code>", ev.At.Format("15:04:05"), html.EscapeString(ev.Source), html.EscapeString(ev.Name))
+			if ev.Text != "" {
+				line += " " + html.EscapeString(ev.Text)
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+
+	return sb.String(), keyboard
+}
+
+// incidentIcon returns the icon for an incident (level while ongoing, ✅ once closed)
+func incidentIcon(inc *incident.Incident) string {
+	if !inc.Ongoing() {
+		return "✅"
+	}
+	if inc.Level == string(health.StatusDown) {
+		return "🛑"
+	}
+	return "🟡"
+}
+
+// handleIncidentsCallback handles incident buttons
+// (incidents:page:server:days:page, incidents:show:ID:server:days:page)
+func (b *Bot) handleIncidentsCallback(callback *tgbotapi.CallbackQuery, parts []string) {
+	if b.incidents == nil {
+		b.answerCallback(callback.ID, "❌ Not enabled")
+		return
+	}
+
+	var text string
+	var keyboard tgbotapi.InlineKeyboardMarkup
+
+	switch parts[1] {
+	case "page":
+		serverID, days, page, ok := b.parseIncidentsListRef(parts[2:])
+		if !ok {
+			b.answerCallback(callback.ID, "❌ Invalid page")
+			return
+		}
+		text, keyboard = b.buildIncidentsMessage(serverID, days, page)
+	case "show":
+		if len(parts) < 3 {
+			b.answerCallback(callback.ID, "❌ Invalid incident")
+			return
+		}
+		id, err := strconv.Atoi(parts[2])
+		if err != nil {
+			b.answerCallback(callback.ID, "❌ Invalid incident")
+			return
+		}
+		serverID, days, page, ok := b.parseIncidentsListRef(parts[3:])
+		if !ok {
+			serverID, days, page = "", incidentsDefaultDays, 0
+		}
+		text, keyboard = b.buildIncidentDetailMessage(id, b.incidentsListRef(serverID, days, page))
+	default:
+		b.answerCallback(callback.ID, "❌ Unknown action")
+		return
+	}
+
+	b.editMessageWithKeyboard(callback.Message.Chat.ID, callback.Message.MessageID, text, keyboard)
+	b.answerCallback(callback.ID, "")
+}
+
+// RecordWebhookEvent records a webhook event in incident history
+// (implements webhook.EventRecorder; source is the upstream name)
+func (b *Bot) RecordWebhookEvent(source, name, summary string, at time.Time) {
+	if b.incidents == nil {
+		return
+	}
+
+	ev := incident.Event{At: at, Source: source, Name: name, Text: summary}
+	if ip := b.config.GetUpstreamIP(source); ip != "" {
+		if server := b.config.GetServerByIP(ip); server != nil {
+			ev.ServerID = server.ID
+		}
+	}
+	b.incidents.RecordEvent(ev)
+}
@@ -2,6 +2,7 @@ package telegram
 
 import (
 	"fmt"
+	"html"
 	"strings"
 	"time"
 
@@ -30,6 +31,88 @@ func formatTimeAgo(t time.Time) string {
 	}
 }
 
+// truncate shortens a string to max characters, adding "..." if truncated
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max]) + "..."
+}
+
+// maxListReasons limits reasons shown per server in the health list
+const maxListReasons = 2
+
+// formatReasons renders status reasons one per line with the given prefix
+// max > 0 limits the number of lines, the rest is summarised as "+N more"
+func formatReasons(reasons []string, prefix string, max int) string {
+	var sb strings.Builder
+	for i, reason := range reasons {
+		if max > 0 && i == max {
+			sb.WriteString(fmt.Sprintf("%s<i>+%d more</i>\n", prefix, len(reasons)-max))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("%s<i>%s</i>\n", prefix, html.EscapeString(truncate(reason, 80))))
+	}
+	return sb.String()
+}
+
+// formatCertInfo renders TLS certificate details for the server detail view
+// label names the check when a server has several
+func formatCertInfo(cert *health.CertInfo, label string, warnDays float64) string {
+	var sb strings.Builder
+
+	days := cert.DaysLeft()
+	icon := "🔒"
+	if !cert.ChainValid || float64(days) < warnDays {
+		icon = "⚠️"
+	}
+	if days < 0 {
+		sb.WriteString(fmt.Sprintf("TLS: %s cert expired %d days ago", icon, -days))
+	} else {
+		sb.WriteString(fmt.Sprintf("TLS: %s cert expires in %d days", icon, days))
+	}
+	sb.WriteString(fmt.Sprintf(" (%s)", cert.NotAfter.Format("2006-01-02")))
+	if label != "" {
+		sb.WriteString(fmt.Sprintf(" <code>%s</code>", html.EscapeString(label)))
+	}
+	sb.WriteString("\n")
+
+	if cert.Issuer != "" {
+		sb.WriteString(fmt.Sprintf("  └ Issuer: %s\n", html.EscapeString(cert.Issuer)))
+	}
+	if len(cert.DNSNames) > 0 {
+		sb.WriteString(fmt.Sprintf("  └ SAN: %s\n", html.EscapeString(formatSANs(cert.DNSNames, 3))))
+	}
+	if !cert.ChainValid {
+		sb.WriteString(fmt.Sprintf("  └ Chain: ❌ <code>%s</code>\n", html.EscapeString(truncate(cert.ChainError, 80))))
+	}
+	return sb.String()
+}
+
+// formatSANs joins up to max certificate names, summarising the rest as "+N"
+func formatSANs(names []string, max int) string {
+	if len(names) <= max {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s, +%d", strings.Join(names[:max], ", "), len(names)-max)
+}
+
+// formatBitrate returns a human-readable network rate from bytes per second
+func formatBitrate(bytesPerSec float64) string {
+	bits := bytesPerSec * 8
+	switch {
+	case bits >= 1e9:
+		return fmt.Sprintf("%.1f Gbit/s", bits/1e9)
+	case bits >= 1e6:
+		return fmt.Sprintf("%.1f Mbit/s", bits/1e6)
+	case bits >= 1e3:
+		return fmt.Sprintf("%.0f kbit/s", bits/1e3)
+	default:
+		return fmt.Sprintf("%.0f bit/s", bits)
+	}
+}
+
 // handleInfra handles the /infra command - infrastructure overview
 func (b *Bot) handleInfra(msg *tgbotapi.Message) {
 	if !b.config.IsInfrastructureEnabled() {
@@ -76,10 +159,41 @@ func (b *Bot) buildInfraMessage() (string, tgbotapi.InlineKeyboardMarkup) {
 		}
 	}
 
+	if b.discovery != nil {
+		sb.WriteString(b.formatDrift())
+	}
+
 	keyboard := b.buildInfraKeyboard()
 	return sb.String(), keyboard
 }
 
+// formatDrift formats servers whose declared services differ from the jobs
+// Prometheus scrapes (➕ scraped, not declared; ➖ declared, not scraped)
+func (b *Bot) formatDrift() string {
+	drifts, err := b.discovery.Drift()
+	if err != nil {
+		return fmt.Sprintf("\n⚠️ Drift unavailable: <code>%s</code>\n", html.EscapeString(truncate(err.Error(), 120)))
+	}
+	if len(drifts) == 0 {
+		return "\n✅ Declared services match Prometheus targets\n"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n🔀 <b>Drift</b> <i>(➕ scraped, not declared · ➖ declared, not scraped)</i>\n")
+	for _, d := range drifts {
+		server := b.config.GetServer(d.ServerID)
+		var parts []string
+		for _, job := range d.Undeclared {
+			parts = append(parts, "➕ "+html.EscapeString(job))
+		}
+		for _, job := range d.Missing {
+			parts = append(parts, "➖ "+html.EscapeString(job))
+		}
+		sb.WriteString(fmt.Sprintf("  • %s %s: %s\n", server.Icon, html.EscapeString(server.Name), strings.Join(parts, " · ")))
+	}
+	return sb.String()
+}
+
 // buildHealthMessage builds the health status message
 // force=true bypasses cache and fetches fresh data
 func (b *Bot) buildHealthMessage(force bool) (string, tgbotapi.InlineKeyboardMarkup) {
@@ -122,7 +236,14 @@ func (b *Bot) buildHealthMessage(force bool) (string, tgbotapi.InlineKeyboardMar
 		for _, status := range servers {
 			statusIcon := status.GetStatusIcon()
 			externalIcon := status.GetExternalIcon()
-			sb.WriteString(fmt.Sprintf("  %s %s %s\n", statusIcon, status.Name, externalIcon))
+			sb.WriteString(fmt.Sprintf("  %s %s %s%s", statusIcon, status.Name, externalIcon, b.silenceMarker(status.ID, "")))
+			if status.ProbeError != "" {
+				sb.WriteString(fmt.Sprintf(" <i>⏱️ %s</i>", status.ProbeError))
+			}
+			sb.WriteString("\n")
+			if status.ProbeError == "" {
+				sb.WriteString(formatReasons(status.GetReasons(), "     └ ", maxListReasons))
+			}
 		}
 	}
 
@@ -134,7 +255,7 @@ func (b *Bot) buildHealthMessage(force bool) (string, tgbotapi.InlineKeyboardMar
 }
 
 // buildServerDetailMessage builds detailed server status message
-// source is "overview" or "health" - determines where Back button leads
+// source is "overview", "health", "alerts" or "targets" - determines where Back button leads
 // force=true bypasses cache and fetches fresh data
 func (b *Bot) buildServerDetailMessage(serverID, source string, force bool) (string, tgbotapi.InlineKeyboardMarkup) {
 	var status *health.ServerStatus
@@ -160,9 +281,44 @@ func (b *Bot) buildServerDetailMessage(serverID, source string, force bool) (str
 	// Header
 	sb.WriteString(fmt.Sprintf("%s <b>%s</b> (<code>%s</code>)\n", status.Icon, status.Name, status.IP))
 	sb.WriteString(fmt.Sprintf("Status: %s %s\n", status.GetStatusIcon(), status.GetStatusLevel()))
+	serverSilenced := false
+	if b.silences != nil {
+		now := time.Now()
+		if s, ok := b.silences.Match(status.ID, "", now); ok {
+			serverSilenced = true
+			sb.WriteString(fmt.Sprintf("Silenced: 🔕 %s left", health.FormatDuration(s.Remaining(now))))
+			if s.Reason != "" {
+				sb.WriteString(fmt.Sprintf(" (%s)", html.EscapeString(s.Reason)))
+			}
+			sb.WriteString("\n")
+		}
+	}
+	if status.ProbeError != "" {
+		sb.WriteString(fmt.Sprintf("Probe: ⏱️ %s\n", status.ProbeError))
+	} else {
+		sb.WriteString(formatReasons(status.GetReasons(), "  • ", 0))
+	}
 
 	// External access
-	if status.ExternalAccess {
+	if len(status.ExternalChecks) > 1 {
+		passed := 0
+		for _, check := range status.ExternalChecks {
+			if check.OK {
+				passed++
+			}
+		}
+		sb.WriteString(fmt.Sprintf("External: %s %d/%d checks passing\n", status.GetExternalIcon(), passed, len(status.ExternalChecks)))
+		for _, check := range status.ExternalChecks {
+			icon := "✅"
+			if !check.OK {
+				icon = "❌"
+			}
+			sb.WriteString(fmt.Sprintf("  • %s <code>%s</code> (%dms)\n", icon, html.EscapeString(check.Label()), check.Latency.Milliseconds()))
+			if !check.OK {
+				sb.WriteString(fmt.Sprintf("    └ <code>%s</code>\n", html.EscapeString(truncate(check.Error, 80))))
+			}
+		}
+	} else if status.ExternalAccess {
 		sb.WriteString(fmt.Sprintf("External: %s accessible", status.GetExternalIcon()))
 		if status.ExternalLatency > 0 {
 			sb.WriteString(fmt.Sprintf(" (%dms)", status.ExternalLatency.Milliseconds()))
@@ -171,11 +327,23 @@ func (b *Bot) buildServerDetailMessage(serverID, source string, force bool) (str
 	} else {
 		sb.WriteString(fmt.Sprintf("External: %s not accessible", status.GetExternalIcon()))
 		if status.ExternalError != "" {
-			sb.WriteString(fmt.Sprintf(" (%s)", status.ExternalError))
+			sb.WriteString(fmt.Sprintf(" (%s)", html.EscapeString(status.ExternalError)))
 		}
 		sb.WriteString("\n")
 	}
 
+	// TLS certificates (https:// external checks)
+	for _, check := range status.ExternalChecks {
+		if check.Cert == nil {
+			continue
+		}
+		label := ""
+		if len(status.ExternalChecks) > 1 {
+			label = check.Label()
+		}
+		sb.WriteString(formatCertInfo(check.Cert, label, status.Thresholds.TLSExpiry.Warn))
+	}
+
 	// SSH statistics (for remote VPS and edge-gateway)
 	// Show section if this server uses SSH (has any stats OR latency recorded)
 	hasSSHStats := status.SSHSuccessCount > 0 || status.SSHErrorCount > 0 || status.SSHLatency > 0
@@ -224,7 +392,13 @@ func (b *Bot) buildServerDetailMessage(serverID, source string, force bool) (str
 			if svc.Port > 0 {
 				sb.WriteString(fmt.Sprintf(" (:%d)", svc.Port))
 			}
+			if !serverSilenced {
+				sb.WriteString(b.silenceMarker(status.ID, svc.Name))
+			}
 			sb.WriteString("\n")
+			if !svc.IsUp && svc.Error != "" {
+				sb.WriteString(fmt.Sprintf("    └ <code>%s</code>\n", html.EscapeString(truncate(svc.Error, 80))))
+			}
 		}
 	}
 
@@ -234,7 +408,11 @@ func (b *Bot) buildServerDetailMessage(serverID, source string, force bool) (str
 
 		// CPU
 		cpuBar := health.FormatProgressBar(status.CPU, 10)
-		sb.WriteString(fmt.Sprintf("• CPU: %.0f%% %s\n", status.CPU, cpuBar))
+		sb.WriteString(fmt.Sprintf("• CPU: %.0f%% %s", status.CPU, cpuBar))
+		if status.CPUCores > 0 {
+			sb.WriteString(fmt.Sprintf(" (%d cores)", status.CPUCores))
+		}
+		sb.WriteString("\n")
 
 		// Memory
 		memBar := health.FormatProgressBar(status.Memory, 10)
@@ -243,8 +421,40 @@ func (b *Bot) buildServerDetailMessage(serverID, source string, force bool) (str
 
 		// Disk
 		diskBar := health.FormatProgressBar(status.Disk, 10)
-		sb.WriteString(fmt.Sprintf("• Disk: %.0f%% %s (%.1f/%.1f GB)\n",
+		sb.WriteString(fmt.Sprintf("• Disk: %.0f%% %s (%.1f/%.1f GB)",
 			status.Disk, diskBar, status.DiskUsedGB, status.DiskTotalGB))
+		for _, f := range status.DiskFill {
+			if isRootFilesystem(f.Mountpoint) {
+				sb.WriteString(" · fills in " + health.FormatETA(f.FillsIn))
+			}
+		}
+		sb.WriteString("\n")
+
+		// Other mounted filesystems (root, or a single filesystem, is shown above)
+		for _, fs := range status.Filesystems {
+			if isRootFilesystem(fs.Mountpoint) || len(status.Filesystems) == 1 {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("  └ <code>%s</code>: %.0f%%", html.EscapeString(fs.Mountpoint), fs.UsedPercent))
+			if fs.TotalGB > 0 {
+				sb.WriteString(fmt.Sprintf(" (%.1f/%.1f GB)", fs.UsedGB, fs.TotalGB))
+			}
+			sb.WriteString("\n")
+		}
+
+		// Other filesystems filling up at the current trend
+		for _, f := range status.DiskFill {
+			if !isRootFilesystem(f.Mountpoint) {
+				sb.WriteString(fmt.Sprintf("  └ <code>%s</code>: fills in %s\n",
+					html.EscapeString(f.Mountpoint), health.FormatETA(f.FillsIn)))
+			}
+		}
+
+		// Network
+		if status.NetworkRxBytesPerSec > 0 || status.NetworkTxBytesPerSec > 0 {
+			sb.WriteString(fmt.Sprintf("• Net: ↓ %s ↑ %s\n",
+				formatBitrate(status.NetworkRxBytesPerSec), formatBitrate(status.NetworkTxBytesPerSec)))
+		}
 
 		// Uptime
 		sb.WriteString(fmt.Sprintf("\n⏱️ <b>Uptime:</b> %s\n", status.FormatUptime()))
@@ -254,6 +464,12 @@ func (b *Bot) buildServerDetailMessage(serverID, source string, force bool) (str
 	return sb.String(), keyboard
 }
 
+// isRootFilesystem reports whether a mountpoint is the one of the Disk line
+// ("/" for node_exporter, "C:" for windows_exporter)
+func isRootFilesystem(mountpoint string) bool {
+	return mountpoint == "/" || mountpoint == "C:"
+}
+
 // handleInfraCallback handles infrastructure-related callbacks
 func (b *Bot) handleInfraCallback(callback *tgbotapi.CallbackQuery, parts []string) {
 	if len(parts) < 2 {
@@ -277,7 +493,7 @@ func (b *Bot) handleInfraCallback(callback *tgbotapi.CallbackQuery, parts []stri
 		b.answerCallback(callback.ID, "← Back")
 
 	case "overview":
-		// Show infrastructure overview (no metrics needed, instant)
+		// Show infrastructure overview (no metrics needed; drift uses cached targets)
 		text, keyboard := b.buildInfraMessage()
 		b.editMessageWithKeyboard(callback.Message.Chat.ID, callback.Message.MessageID, text, keyboard)
 		b.answerCallback(callback.ID, "🏗️ Infrastructure")
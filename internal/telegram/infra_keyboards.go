@@ -77,17 +77,35 @@ func (b *Bot) buildHealthKeyboard(_ []*health.ServerStatus) tgbotapi.InlineKeybo
 }
 
 // buildServerDetailKeyboard builds the server detail view keyboard
-// source is "overview" or "health" - determines where Back button leads
+// source is "overview", "health", "alerts" or "targets" - determines where Back button leads
 func (b *Bot) buildServerDetailKeyboard(serverID, source string) tgbotapi.InlineKeyboardMarkup {
 	backCallback := "infra:overview"
-	if source == "health" {
+	switch source {
+	case "health":
 		backCallback = "infra:health_back" // uses cache, not force refresh
+	case "alerts", "targets":
+		backCallback = "prom:" + source
 	}
 
-	return tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("← Back", backCallback),
-			tgbotapi.NewInlineKeyboardButtonData("🔄 Refresh", fmt.Sprintf("infra:server_refresh:%s:%s", serverID, source)),
-		),
-	)
+	var rows [][]tgbotapi.InlineKeyboardButton
+
+	// Charts are available for servers with Prometheus metrics
+	if b.healthChecker != nil {
+		if _, err := b.healthChecker.PrometheusTarget(serverID); err == nil {
+			var row []tgbotapi.InlineKeyboardButton
+			for _, resource := range graphResourceOrder {
+				label := fmt.Sprintf("📈 %s 24h", graphResources[resource].label)
+				callback := fmt.Sprintf("graph:%s:%s:24h", serverID, resource)
+				row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, callback))
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("← Back", backCallback),
+		tgbotapi.NewInlineKeyboardButtonData("🔄 Refresh", fmt.Sprintf("infra:server_refresh:%s:%s", serverID, source)),
+	))
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
 }
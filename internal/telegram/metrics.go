@@ -0,0 +1,117 @@
+package telegram
+
+import (
+	"sort"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/health"
+	"github.com/scinfra-pro/scinfra-bot/internal/metrics"
+)
+
+// collectMetrics adds health and SSH metrics at scrape time
+// Health metrics come from the last check; scraping never triggers probes
+func (b *Bot) collectMetrics(add func(metrics.Family)) {
+	b.collectSSHMetrics(add)
+
+	if b.healthChecker == nil {
+		return
+	}
+	statuses, checkedAt := b.healthChecker.LastStatuses()
+	if len(statuses) == 0 {
+		return
+	}
+
+	level := metrics.Family{Name: "scinfra_bot_server_health_level", Type: metrics.TypeGauge,
+		Help: "Server health level (0 up, 1 degraded, 2 down)"}
+	up := metrics.Family{Name: "scinfra_bot_server_up", Type: metrics.TypeGauge,
+		Help: "Whether the server is reachable (1) or down (0)"}
+	services := metrics.Family{Name: "scinfra_bot_service_up", Type: metrics.TypeGauge,
+		Help: "Whether the service check passed (1) or failed (0)"}
+	extOK := metrics.Family{Name: "scinfra_bot_external_check_success", Type: metrics.TypeGauge,
+		Help: "Whether the external check passed (1) or failed (0)"}
+	extLatency := metrics.Family{Name: "scinfra_bot_external_check_latency_seconds", Type: metrics.TypeGauge,
+		Help: "Latency of the external check"}
+
+	for _, status := range statuses {
+		server := []string{"server", status.ID, "cloud", status.CloudName}
+		level.Samples = append(level.Samples, metrics.Sample{Labels: server, Value: levelValue(status.GetStatusLevel())})
+		up.Samples = append(up.Samples, metrics.Sample{Labels: server, Value: boolValue(status.IsUp)})
+
+		for _, svc := range status.Services {
+			services.Samples = append(services.Samples, metrics.Sample{
+				Labels: []string{"server", status.ID, "service", svc.Name},
+				Value:  boolValue(svc.IsUp),
+			})
+		}
+
+		for _, check := range status.ExternalChecks {
+			labels := []string{"server", status.ID, "target", check.Target}
+			extOK.Samples = append(extOK.Samples, metrics.Sample{Labels: labels, Value: boolValue(check.OK)})
+			if check.OK {
+				extLatency.Samples = append(extLatency.Samples, metrics.Sample{Labels: labels, Value: check.Latency.Seconds()})
+			}
+		}
+	}
+
+	add(level)
+	add(up)
+	add(services)
+	add(extOK)
+	add(extLatency)
+	add(metrics.Family{Name: "scinfra_bot_health_last_check_timestamp_seconds", Type: metrics.TypeGauge,
+		Help:    "Unix time of the last health check",
+		Samples: []metrics.Sample{{Value: float64(checkedAt.Unix())}}})
+}
+
+// collectSSHMetrics adds SSH counters and latency for edge and switch-gate targets
+func (b *Bot) collectSSHMetrics(add func(metrics.Family)) {
+	requests := metrics.Family{Name: "scinfra_bot_ssh_requests_total", Type: metrics.TypeCounter,
+		Help: "SSH requests by target and result"}
+	latency := metrics.Family{Name: "scinfra_bot_ssh_latency_seconds", Type: metrics.TypeGauge,
+		Help: "Latency of the last SSH request by target"}
+
+	addTarget := func(target string, success, errors int, last float64) {
+		requests.Samples = append(requests.Samples,
+			metrics.Sample{Labels: []string{"target", target, "result", "success"}, Value: float64(success)},
+			metrics.Sample{Labels: []string{"target", target, "result", "error"}, Value: float64(errors)},
+		)
+		latency.Samples = append(latency.Samples, metrics.Sample{Labels: []string{"target", target}, Value: last})
+	}
+
+	if b.edgeClient != nil {
+		stats := b.edgeClient.GetSSHStats()
+		addTarget("edge", stats.SuccessCount, stats.ErrorCount, stats.LastLatency.Seconds())
+	}
+
+	names := make([]string, 0, len(b.switchGateClients))
+	for name := range b.switchGateClients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		stats := b.switchGateClients[name].GetSSHStats()
+		addTarget(name, stats.SuccessCount, stats.ErrorCount, stats.LastLatency.Seconds())
+	}
+
+	add(requests)
+	add(latency)
+}
+
+// levelValue maps a health level to 0 (up), 1 (degraded) or 2 (down)
+func levelValue(level health.StatusLevel) float64 {
+	switch level {
+	case health.StatusUp:
+		return 0
+	case health.StatusDegraded:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// boolValue maps true to 1 and false to 0
+func boolValue(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
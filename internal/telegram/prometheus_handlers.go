@@ -0,0 +1,292 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/config"
+	"github.com/scinfra-pro/scinfra-bot/internal/health"
+	"github.com/scinfra-pro/scinfra-bot/internal/prometheus"
+)
+
+// promListLimit limits alerts and targets listed in one message
+const promListLimit = 25
+
+// promListMaxBytes stops a list before it outgrows a Telegram message (4096 characters)
+const promListMaxBytes = 3800
+
+// sourcedAlert is a Prometheus alert and the data source it came from
+type sourcedAlert struct {
+	prometheus.Alert
+	datasource string
+}
+
+// sourcedTarget is a scrape target and the data source it came from
+type sourcedTarget struct {
+	prometheus.Target
+	datasource string
+}
+
+// handleAlerts handles /alerts
+func (b *Bot) handleAlerts(msg *tgbotapi.Message) {
+	if b.healthChecker == nil {
+		b.reply(msg.Chat.ID, "❌ Infrastructure monitoring is not enabled.")
+		return
+	}
+	text, keyboard := b.buildAlertsMessage()
+	b.replyWithKeyboard(msg.Chat.ID, text, keyboard)
+}
+
+// handleTargets handles /targets
+func (b *Bot) handleTargets(msg *tgbotapi.Message) {
+	if b.healthChecker == nil {
+		b.reply(msg.Chat.ID, "❌ Infrastructure monitoring is not enabled.")
+		return
+	}
+	text, keyboard := b.buildTargetsMessage()
+	b.replyWithKeyboard(msg.Chat.ID, text, keyboard)
+}
+
+// handlePromCallback handles alert and target list buttons (prom:alerts, prom:targets)
+func (b *Bot) handlePromCallback(callback *tgbotapi.CallbackQuery, parts []string) {
+	if b.healthChecker == nil {
+		b.answerCallback(callback.ID, "❌ Not enabled")
+		return
+	}
+
+	switch parts[1] {
+	case "alerts":
+		text, keyboard := b.buildAlertsMessage()
+		b.editMessageWithKeyboard(callback.Message.Chat.ID, callback.Message.MessageID, text, keyboard)
+		b.answerCallback(callback.ID, "🚨 Alerts")
+	case "targets":
+		text, keyboard := b.buildTargetsMessage()
+		b.editMessageWithKeyboard(callback.Message.Chat.ID, callback.Message.MessageID, text, keyboard)
+		b.answerCallback(callback.ID, "🎯 Targets")
+	default:
+		b.answerCallback(callback.ID, "❌ Unknown action")
+	}
+}
+
+// buildAlertsMessage lists firing and pending alerts of all used data sources
+func (b *Bot) buildAlertsMessage() (string, tgbotapi.InlineKeyboardMarkup) {
+	var alerts []sourcedAlert
+	var errs []string
+	datasources := b.healthChecker.UsedDatasources()
+	for _, name := range datasources {
+		client, err := b.healthChecker.Datasource(name)
+		if err == nil {
+			var list []prometheus.Alert
+			if list, err = client.Alerts(); err == nil {
+				for _, a := range list {
+					alerts = append(alerts, sourcedAlert{Alert: a, datasource: name})
+				}
+				continue
+			}
+		}
+		errs = append(errs, fmt.Sprintf("⚠️ %s: <code>%s</code>", html.EscapeString(name), html.EscapeString(truncate(err.Error(), 120))))
+	}
+
+	// Firing before pending, longest active first
+	sort.SliceStable(alerts, func(i, j int) bool {
+		if alerts[i].State != alerts[j].State {
+			return alerts[i].State == prometheus.AlertFiring
+		}
+		return alerts[i].ActiveAt.Before(alerts[j].ActiveAt)
+	})
+
+	firing := 0
+	for _, a := range alerts {
+		if a.State == prometheus.AlertFiring {
+			firing++
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🚨 <b>Prometheus Alerts</b> — %d firing, %d pending\n", firing, len(alerts)-firing))
+	for _, e := range errs {
+		sb.WriteString(e + "\n")
+	}
+	if len(alerts) == 0 && len(errs) == 0 {
+		sb.WriteString("\n✅ No active alerts\n")
+	}
+
+	now := time.Now()
+	var servers []*config.ServerConfig
+	for i, a := range alerts {
+		icon := "🔴"
+		if a.State == prometheus.AlertPending {
+			icon = "🟡"
+		}
+		server := b.config.GetServerByInstance(a.Labels["instance"])
+
+		var entry strings.Builder
+		entry.WriteString(fmt.Sprintf("\n%s <b>%s</b>", icon, html.EscapeString(a.Name())))
+		if target := promTarget(server, a.Labels["instance"]); target != "" {
+			entry.WriteString(" · " + target)
+		}
+		if len(datasources) > 1 {
+			entry.WriteString(fmt.Sprintf(" · <i>%s</i>", html.EscapeString(a.datasource)))
+		}
+		entry.WriteString("\n")
+
+		if labels := formatAlertLabels(a.Labels); labels != "" {
+			entry.WriteString(fmt.Sprintf("   <code>%s</code>\n", html.EscapeString(truncate(labels, 200))))
+		}
+		if summary := a.Annotations["summary"]; summary != "" {
+			entry.WriteString(fmt.Sprintf("   %s\n", html.EscapeString(truncate(summary, 120))))
+		}
+		if !a.ActiveAt.IsZero() {
+			entry.WriteString(fmt.Sprintf("   <i>%s for %s</i>\n", a.State, health.FormatDuration(now.Sub(a.ActiveAt))))
+		}
+
+		if i == promListLimit || sb.Len()+entry.Len() > promListMaxBytes {
+			sb.WriteString(fmt.Sprintf("\n<i>+%d more</i>\n", len(alerts)-i))
+			break
+		}
+		sb.WriteString(entry.String())
+		servers = appendServer(servers, server)
+	}
+
+	return sb.String(), promListKeyboard(servers, "alerts")
+}
+
+// buildTargetsMessage lists scrape targets that are down with their last error
+func (b *Bot) buildTargetsMessage() (string, tgbotapi.InlineKeyboardMarkup) {
+	var down []sourcedTarget
+	var errs []string
+	total := 0
+	datasources := b.healthChecker.UsedDatasources()
+	for _, name := range datasources {
+		client, err := b.healthChecker.Datasource(name)
+		if err == nil {
+			var list []prometheus.Target
+			if list, err = client.Targets(); err == nil {
+				total += len(list)
+				for _, t := range list {
+					if t.Health == prometheus.TargetDown {
+						down = append(down, sourcedTarget{Target: t, datasource: name})
+					}
+				}
+				continue
+			}
+		}
+		errs = append(errs, fmt.Sprintf("⚠️ %s: <code>%s</code>", html.EscapeString(name), html.EscapeString(truncate(err.Error(), 120))))
+	}
+
+	sort.SliceStable(down, func(i, j int) bool {
+		if down[i].Job() != down[j].Job() {
+			return down[i].Job() < down[j].Job()
+		}
+		return down[i].Instance() < down[j].Instance()
+	})
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🎯 <b>Scrape Targets</b> — %d of %d down\n", len(down), total))
+	for _, e := range errs {
+		sb.WriteString(e + "\n")
+	}
+	if len(down) == 0 && len(errs) == 0 {
+		sb.WriteString(fmt.Sprintf("\n✅ All %d targets are up\n", total))
+	}
+
+	var servers []*config.ServerConfig
+	for i, t := range down {
+		server := b.config.GetServerByInstance(t.Instance())
+
+		var entry strings.Builder
+		entry.WriteString(fmt.Sprintf("\n🛑 <b>%s</b>", html.EscapeString(t.Job())))
+		if target := promTarget(server, t.Instance()); target != "" {
+			entry.WriteString(" · " + target)
+		}
+		if len(datasources) > 1 {
+			entry.WriteString(fmt.Sprintf(" · <i>%s</i>", html.EscapeString(t.datasource)))
+		}
+		entry.WriteString("\n")
+
+		if t.LastError != "" {
+			entry.WriteString(fmt.Sprintf("   <code>%s</code>\n", html.EscapeString(truncate(t.LastError, 160))))
+		}
+		entry.WriteString(fmt.Sprintf("   <i>last scrape %s</i>\n", formatTimeAgo(t.LastScrape)))
+
+		if i == promListLimit || sb.Len()+entry.Len() > promListMaxBytes {
+			sb.WriteString(fmt.Sprintf("\n<i>+%d more</i>\n", len(down)-i))
+			break
+		}
+		sb.WriteString(entry.String())
+		servers = appendServer(servers, server)
+	}
+
+	return sb.String(), promListKeyboard(servers, "targets")
+}
+
+// promTarget returns the configured server for an instance label, or the raw
+// instance if the server is unknown
+func promTarget(server *config.ServerConfig, instance string) string {
+	if server != nil {
+		return fmt.Sprintf("%s %s", server.Icon, html.EscapeString(server.Name))
+	}
+	if instance != "" {
+		return fmt.Sprintf("<code>%s</code>", html.EscapeString(instance))
+	}
+	return ""
+}
+
+// formatAlertLabels formats alert labels as k=v pairs, without alertname and instance
+func formatAlertLabels(labels map[string]string) string {
+	var pairs []string
+	for k, v := range labels {
+		if k == "alertname" || k == "instance" {
+			continue
+		}
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// appendServer adds a server to the list once (nil is ignored)
+func appendServer(servers []*config.ServerConfig, server *config.ServerConfig) []*config.ServerConfig {
+	if server == nil {
+		return servers
+	}
+	for _, s := range servers {
+		if s.ID == server.ID {
+			return servers
+		}
+	}
+	return append(servers, server)
+}
+
+// promListKeyboard links the servers of an alert or target list to their
+// detail pages (source is "alerts" or "targets", so Back returns to the list)
+func promListKeyboard(servers []*config.ServerConfig, source string) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+
+	for _, server := range servers {
+		label := fmt.Sprintf("%s %s", server.Icon, server.Name)
+		callback := fmt.Sprintf("infra:server:%s:%s", server.ID, source)
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, callback))
+
+		// Max 3 buttons per row
+		if len(row) >= 3 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔄 Refresh", "prom:"+source),
+	))
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
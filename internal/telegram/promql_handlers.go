@@ -0,0 +1,324 @@
+package telegram
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/chart"
+	"github.com/scinfra-pro/scinfra-bot/internal/config"
+	"github.com/scinfra-pro/scinfra-bot/internal/prometheus"
+)
+
+// promqlMaxText is the longest table sent as a message; longer results are sent as CSV
+const promqlMaxText = 3500
+
+// promqlMaxTitle is the longest expression shown in headers and captions
+// (photo captions are limited to 1024 characters)
+const promqlMaxTitle = 200
+
+// promqlUsage is the /promql usage message
+const promqlUsage = "🔎 <b>Usage:</b> <code>/promql [@datasource] &lt;expr&gt;</code>\n\n" +
+	"Vectors are shown as a table, range selectors (<code>expr[1h]</code>) as a chart."
+
+// handlePromQL handles /promql [@datasource] <expr> (admin role by default)
+func (b *Bot) handlePromQL(msg *tgbotapi.Message, args string) {
+	if b.healthChecker == nil {
+		b.reply(msg.Chat.ID, "❌ Infrastructure monitoring is not enabled.")
+		return
+	}
+
+	datasource := ""
+	expr := strings.TrimSpace(args)
+	if strings.HasPrefix(expr, "@") {
+		name, rest, _ := strings.Cut(expr, " ")
+		datasource = strings.TrimPrefix(name, "@")
+		expr = strings.TrimSpace(rest)
+	}
+	if expr == "" {
+		b.reply(msg.Chat.ID, promqlUsage)
+		return
+	}
+
+	log.Printf("PromQL from user %d: %s", userID(msg.From), expr)
+	b.runPromQL(msg.Chat.ID, datasource, expr, "")
+}
+
+// handleSavedQuery handles /q [name]: lists saved queries or runs one
+func (b *Bot) handleSavedQuery(msg *tgbotapi.Message, args string) {
+	if b.healthChecker == nil {
+		b.reply(msg.Chat.ID, "❌ Infrastructure monitoring is not enabled.")
+		return
+	}
+
+	queries := b.config.Infrastructure.PromQL.Queries
+	name := strings.TrimSpace(args)
+	if name == "" {
+		b.reply(msg.Chat.ID, formatSavedQueries(queries))
+		return
+	}
+
+	q, ok := queries[name]
+	if !ok {
+		b.reply(msg.Chat.ID, fmt.Sprintf("❌ Unknown query: <code>%s</code>\nUse /q to list saved queries.", html.EscapeString(name)))
+		return
+	}
+	b.runPromQL(msg.Chat.ID, q.Datasource, q.Query, name)
+}
+
+// formatSavedQueries lists saved queries for /q
+func formatSavedQueries(queries map[string]config.SavedQuery) string {
+	if len(queries) == 0 {
+		return "🔎 No saved queries. Add them to <code>infrastructure.promql.queries</code>."
+	}
+
+	names := make([]string, 0, len(queries))
+	for name := range queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString("🔎 <b>Saved queries</b>\n\n")
+	for _, name := range names {
+		sb.WriteString(fmt.Sprintf("/q <code>%s</code>", html.EscapeString(name)))
+		if desc := queries[name].Description; desc != "" {
+			sb.WriteString(" - " + html.EscapeString(desc))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// runPromQL runs an instant query and sends the result: vectors as a table,
+// matrices as a chart, results with more than max_rows series as CSV
+// name is the saved query name (empty for ad-hoc queries)
+func (b *Bot) runPromQL(chatID int64, datasource, expr, name string) {
+	client, err := b.healthChecker.Datasource(datasource)
+	if err != nil {
+		b.reply(chatID, fmt.Sprintf("❌ %s", html.EscapeString(err.Error())))
+		return
+	}
+
+	value, err := client.QueryValue(expr)
+	if err != nil {
+		b.reply(chatID, fmt.Sprintf("❌ <code>%s</code>", html.EscapeString(err.Error())))
+		return
+	}
+
+	title := name
+	if title == "" {
+		title = expr
+	}
+	header := fmt.Sprintf("🔎 <code>%s</code>", html.EscapeString(truncate(title, promqlMaxTitle)))
+	fileName := "promql"
+	if name != "" {
+		fileName = name
+	}
+	fileName += "-" + time.Now().Format("20060102-150405")
+	maxRows := b.config.Infrastructure.PromQL.MaxRows
+
+	switch value.Type {
+	case prometheus.ValueScalar:
+		b.reply(chatID, fmt.Sprintf("%s\n\n<pre>%s</pre>", header, formatPromValue(value.Scalar.Value)))
+
+	case prometheus.ValueString:
+		b.reply(chatID, fmt.Sprintf("%s\n\n<pre>%s</pre>", header, html.EscapeString(value.String)))
+
+	case prometheus.ValueVector:
+		if len(value.Vector) == 0 {
+			b.reply(chatID, header+"\n\nEmpty result.")
+			return
+		}
+		table := formatVectorTable(value.Vector)
+		if len(value.Vector) > maxRows || len(table) > promqlMaxText {
+			b.sendPromQLCSV(chatID, fileName, value, title)
+			return
+		}
+		b.reply(chatID, fmt.Sprintf("%s — %d series\n\n<pre>%s</pre>", header, len(value.Vector), html.EscapeString(table)))
+
+	case prometheus.ValueMatrix:
+		if len(value.Matrix) > maxRows {
+			b.sendPromQLCSV(chatID, fileName, value, title)
+			return
+		}
+		c := &chart.Chart{Title: truncate(title, 60), Kind: chart.KindLine}
+		for _, s := range value.Matrix {
+			c.Series = append(c.Series, chart.Series{Name: seriesLabel(s.Metric), Points: chartPoints(s.Points)})
+		}
+		if len(c.Series) == 1 {
+			c.Series[0].Name = ""
+		}
+		data, err := c.Render()
+		if err == chart.ErrNoData {
+			b.reply(chatID, header+"\n\nEmpty result.")
+			return
+		}
+		if err != nil {
+			b.reply(chatID, fmt.Sprintf("❌ %s", html.EscapeString(err.Error())))
+			return
+		}
+		b.sendPhoto(chatID, fileName+".png", data, fmt.Sprintf("%s — %d series", header, len(value.Matrix)))
+	}
+}
+
+// sendPromQLCSV sends a vector or matrix result as a CSV document
+func (b *Bot) sendPromQLCSV(chatID int64, fileName string, value *prometheus.Value, title string) {
+	data, rows, err := promqlCSV(value)
+	if err != nil {
+		b.reply(chatID, fmt.Sprintf("❌ %s", html.EscapeString(err.Error())))
+		return
+	}
+	caption := fmt.Sprintf("📄 %s — %d rows", truncate(title, promqlMaxTitle), rows)
+	b.sendDocument(chatID, fileName+".csv", data, caption)
+}
+
+// formatVectorTable renders a vector as an aligned table with one column per
+// label (the metric name first) and the value last
+func formatVectorTable(results []prometheus.QueryResult) string {
+	labels := labelNames(results)
+
+	header := make([]string, 0, len(labels)+1)
+	for _, l := range labels {
+		if l == "__name__" {
+			l = "metric"
+		}
+		header = append(header, l)
+	}
+	header = append(header, "value")
+
+	rows := [][]string{header}
+	for _, r := range results {
+		row := make([]string, 0, len(header))
+		for _, l := range labels {
+			row = append(row, r.Metric[l])
+		}
+		rows = append(rows, append(row, formatPromValue(r.Value)))
+	}
+
+	widths := make([]int, len(header))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	var sb strings.Builder
+	for _, row := range rows {
+		for i, cell := range row {
+			pad := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+			switch {
+			case i == len(row)-1:
+				sb.WriteString(pad + cell) // values are right-aligned
+			default:
+				sb.WriteString(cell + pad + "  ")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// promqlCSV renders a vector or matrix as CSV and returns the number of data rows
+func promqlCSV(value *prometheus.Value) ([]byte, int, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	var labels []string
+	var records [][]string
+	switch value.Type {
+	case prometheus.ValueVector:
+		labels = labelNames(value.Vector)
+		records = append(records, append(append([]string{}, labels...), "value"))
+		for _, r := range value.Vector {
+			records = append(records, append(labelValues(r.Metric, labels), formatPromValue(r.Value)))
+		}
+	case prometheus.ValueMatrix:
+		var results []prometheus.QueryResult
+		for _, s := range value.Matrix {
+			results = append(results, prometheus.QueryResult{Metric: s.Metric})
+		}
+		labels = labelNames(results)
+		records = append(records, append(append([]string{}, labels...), "timestamp", "value"))
+		for _, s := range value.Matrix {
+			for _, p := range s.Points {
+				records = append(records, append(labelValues(s.Metric, labels),
+					p.Time.UTC().Format(time.RFC3339), formatPromValue(p.Value)))
+			}
+		}
+	default:
+		return nil, 0, fmt.Errorf("cannot export %s result as CSV", value.Type)
+	}
+
+	if err := w.WriteAll(records); err != nil {
+		return nil, 0, fmt.Errorf("write csv: %w", err)
+	}
+	return buf.Bytes(), len(records) - 1, nil
+}
+
+// labelNames returns the label names of results, __name__ first, then sorted
+func labelNames(results []prometheus.QueryResult) []string {
+	seen := map[string]bool{}
+	for _, r := range results {
+		for l := range r.Metric {
+			seen[l] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for l := range seen {
+		if l != "__name__" {
+			names = append(names, l)
+		}
+	}
+	sort.Strings(names)
+	if seen["__name__"] {
+		names = append([]string{"__name__"}, names...)
+	}
+	return names
+}
+
+// labelValues returns the values of the given labels ("" if missing)
+func labelValues(metric map[string]string, labels []string) []string {
+	values := make([]string, len(labels))
+	for i, l := range labels {
+		values[i] = metric[l]
+	}
+	return values
+}
+
+// seriesLabel formats a label set as name{label="value", ...}
+func seriesLabel(metric map[string]string) string {
+	var pairs []string
+	for l, v := range metric {
+		if l != "__name__" {
+			pairs = append(pairs, prometheus.Matcher(l, prometheus.MatchEqual, v))
+		}
+	}
+	sort.Strings(pairs)
+	if len(pairs) == 0 {
+		return metric["__name__"]
+	}
+	return metric["__name__"] + "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// formatPromValue formats a sample value: integers as is, others rounded to 4 decimals
+func formatPromValue(v float64) string {
+	switch {
+	case math.IsNaN(v) || math.IsInf(v, 0):
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case v == math.Trunc(v) && math.Abs(v) < 1e15:
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	default:
+		return strconv.FormatFloat(math.Round(v*1e4)/1e4, 'f', -1, 64)
+	}
+}
@@ -0,0 +1,183 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/health"
+	"github.com/scinfra-pro/scinfra-bot/internal/silence"
+)
+
+// silenceExtendStep is added to a silence by the "➕ 1h" button
+const silenceExtendStep = time.Hour
+
+// handleSilence handles /silence <server|service|cloud> <duration> [reason]
+func (b *Bot) handleSilence(msg *tgbotapi.Message, args string) {
+	if b.silences == nil {
+		b.reply(msg.Chat.ID, "❌ Infrastructure monitoring is not enabled.")
+		return
+	}
+
+	fields := strings.Fields(args)
+	if len(fields) < 2 {
+		b.reply(msg.Chat.ID, "🔕 <b>Usage:</b> <code>/silence &lt;target&gt; &lt;duration&gt; [reason]</code>\n\n"+
+			"Target: server ID, <code>server/service</code> or cloud name (<code>_</code> for spaces)\n"+
+			"Duration: <code>30m</code>, <code>2h</code>, <code>1d</code>\n\n"+
+			"Example: <code>/silence db-server 2h kernel update</code>\n"+
+			"Active silences: /maintenance")
+		return
+	}
+
+	d, err := silence.ParseDuration(fields[1])
+	if err != nil {
+		b.reply(msg.Chat.ID, fmt.Sprintf("❌ %s", html.EscapeString(err.Error())))
+		return
+	}
+	reason := strings.Join(fields[2:], " ")
+
+	createdBy := ""
+	if msg.From != nil {
+		createdBy = msg.From.UserName
+	}
+
+	s, err := b.silences.Add(fields[0], d, reason, createdBy)
+	if err != nil {
+		b.reply(msg.Chat.ID, fmt.Sprintf("❌ %s", html.EscapeString(err.Error())))
+		return
+	}
+
+	text := fmt.Sprintf("🔕 Silenced %s <b>%s</b> for %s (until %s)",
+		s.Kind, html.EscapeString(s.Target), health.FormatDuration(d), s.EndsAt.Format("Jan 2 15:04"))
+	if reason != "" {
+		text += fmt.Sprintf("\nReason: %s", html.EscapeString(reason))
+	}
+	b.replyWithKeyboard(msg.Chat.ID, text, buildSilenceKeyboard([]silence.Silence{*s}))
+}
+
+// handleMaintenance handles /maintenance - lists active silences
+func (b *Bot) handleMaintenance(msg *tgbotapi.Message) {
+	if b.silences == nil {
+		b.reply(msg.Chat.ID, "❌ Infrastructure monitoring is not enabled.")
+		return
+	}
+
+	text, keyboard := b.buildMaintenanceMessage()
+	b.replyWithKeyboard(msg.Chat.ID, text, keyboard)
+}
+
+// buildMaintenanceMessage builds the list of active silences and maintenance windows
+func (b *Bot) buildMaintenanceMessage() (string, tgbotapi.InlineKeyboardMarkup) {
+	now := time.Now()
+	active := b.silences.Active(now)
+
+	var sb strings.Builder
+	sb.WriteString("🔕 <b>Silences &amp; Maintenance</b>\n")
+
+	if len(active) == 0 {
+		sb.WriteString("\nNo active silences.\n")
+		sb.WriteString("\nUse <code>/silence &lt;target&gt; &lt;duration&gt; [reason]</code>")
+	}
+
+	for _, s := range active {
+		label := fmt.Sprintf("#%d", s.ID)
+		if s.Scheduled {
+			label = "🗓️ scheduled"
+		}
+		sb.WriteString(fmt.Sprintf("\n%s %s <b>%s</b>\n", label, s.Kind, html.EscapeString(s.Target)))
+		sb.WriteString(fmt.Sprintf("  ⏳ %s left (until %s)\n", health.FormatDuration(s.Remaining(now)), s.EndsAt.Format("Jan 2 15:04")))
+		if s.Reason != "" {
+			sb.WriteString(fmt.Sprintf("  📝 %s\n", html.EscapeString(s.Reason)))
+		}
+		if s.CreatedBy != "" {
+			sb.WriteString(fmt.Sprintf("  👤 @%s\n", html.EscapeString(s.CreatedBy)))
+		}
+	}
+
+	return sb.String(), buildSilenceKeyboard(active)
+}
+
+// buildSilenceKeyboard builds expire/extend buttons for manual silences
+func buildSilenceKeyboard(silences []silence.Silence) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, s := range silences {
+		if s.Scheduled {
+			continue // scheduled windows are changed in config
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("⏹ Expire #%d", s.ID), fmt.Sprintf("silence:expire:%d", s.ID)),
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("➕ 1h #%d", s.ID), fmt.Sprintf("silence:extend:%d", s.ID)),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔄 Refresh", "silence:list"),
+	))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// handleSilenceCallback handles silence buttons (silence:list, silence:expire:ID, silence:extend:ID)
+func (b *Bot) handleSilenceCallback(callback *tgbotapi.CallbackQuery, parts []string) {
+	if b.silences == nil {
+		b.answerCallback(callback.ID, "❌ Not enabled")
+		return
+	}
+
+	action := parts[1]
+	toast := "🔕 Silences"
+
+	if action == "expire" || action == "extend" {
+		if len(parts) < 3 {
+			b.answerCallback(callback.ID, "❌ Invalid silence")
+			return
+		}
+		id, err := strconv.Atoi(parts[2])
+		if err != nil {
+			b.answerCallback(callback.ID, "❌ Invalid silence")
+			return
+		}
+
+		if action == "expire" {
+			_, err = b.silences.Expire(id)
+			toast = fmt.Sprintf("⏹ Silence #%d expired", id)
+		} else {
+			_, err = b.silences.Extend(id, silenceExtendStep)
+			toast = fmt.Sprintf("➕ Silence #%d extended", id)
+		}
+		if err != nil {
+			toast = "❌ " + err.Error()
+		}
+	} else if action != "list" {
+		b.answerCallback(callback.ID, "❌ Unknown action")
+		return
+	}
+
+	text, keyboard := b.buildMaintenanceMessage()
+	b.editMessageWithKeyboard(callback.Message.Chat.ID, callback.Message.MessageID, text, keyboard)
+	b.answerCallback(callback.ID, toast)
+}
+
+// silenceMarker returns " 🔕 1h20m" if the server (or service) is silenced
+func (b *Bot) silenceMarker(serverID, service string) string {
+	if b.silences == nil {
+		return ""
+	}
+	now := time.Now()
+	s, ok := b.silences.Match(serverID, service, now)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(" 🔕 %s", health.FormatDuration(s.Remaining(now)))
+}
+
+// IsSilenced reports whether notifications for a server or service are suppressed
+// (implements health.Silencer for the webhook server; false without silences)
+func (b *Bot) IsSilenced(serverID, service string) bool {
+	if b.silences == nil {
+		return false
+	}
+	return b.silences.IsSilenced(serverID, service)
+}
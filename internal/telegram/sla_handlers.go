@@ -0,0 +1,247 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/config"
+	"github.com/scinfra-pro/scinfra-bot/internal/health"
+	"github.com/scinfra-pro/scinfra-bot/internal/sla"
+)
+
+// slaDefaultPeriod is used by /sla without arguments
+const slaDefaultPeriod = config.PeriodMonth
+
+// handleSLA handles /sla [day|week|month] [csv]
+func (b *Bot) handleSLA(msg *tgbotapi.Message, args string) {
+	if b.availability == nil {
+		b.reply(msg.Chat.ID, "❌ Infrastructure monitoring is not enabled.")
+		return
+	}
+
+	period := slaDefaultPeriod
+	asCSV := false
+	for _, field := range strings.Fields(strings.ToLower(args)) {
+		switch field {
+		case config.PeriodDay, config.PeriodWeek, config.PeriodMonth:
+			period = field
+		case "csv":
+			asCSV = true
+		default:
+			b.reply(msg.Chat.ID, "📈 <b>Usage:</b> <code>/sla [day|week|month] [csv]</code>")
+			return
+		}
+	}
+
+	if asCSV {
+		b.sendSLACSV(msg.Chat.ID, period)
+		return
+	}
+	text, keyboard := b.buildSLAMessage(period)
+	b.replyWithKeyboard(msg.Chat.ID, text, keyboard)
+}
+
+// buildSLAReport computes the availability report for a period ending now
+func (b *Bot) buildSLAReport(period string) (sla.Report, error) {
+	now := time.Now()
+	start, err := sla.PeriodStart(period, now)
+	if err != nil {
+		return sla.Report{}, err
+	}
+	counts := b.availability.Counts(start, now)
+	incidents := b.incidents.List("", start)
+	return sla.Build(b.config, counts, incidents, period, start, now), nil
+}
+
+// buildSLAMessage builds the availability report message
+func (b *Bot) buildSLAMessage(period string) (string, tgbotapi.InlineKeyboardMarkup) {
+	keyboard := buildSLAKeyboard(period)
+
+	report, err := b.buildSLAReport(period)
+	if err != nil {
+		return fmt.Sprintf("❌ %s", html.EscapeString(err.Error())), keyboard
+	}
+	return formatSLAReport(b.config, &report), keyboard
+}
+
+// formatSLAReport formats a report grouped by cloud
+// Services are listed only if they were not fully available
+func formatSLAReport(cfg *config.Config, report *sla.Report) string {
+	icons := make(map[string]string)
+	for _, cloud := range cfg.Infrastructure.Clouds {
+		icons[cloud.Name] = cloud.Icon
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📈 <b>Availability</b> — last %s\n", periodLabel(report.Period)))
+	sb.WriteString(fmt.Sprintf("<i>%s – %s</i>\n", report.Start.Format("Jan 2 15:04"), report.End.Format("Jan 2 15:04")))
+
+	for _, row := range report.Rows {
+		pct, ok := row.Counts.Availability()
+		switch row.Kind {
+		case sla.KindCloud:
+			sb.WriteString(fmt.Sprintf("\n%s <b>%s</b> %s%s\n", icons[row.Cloud], html.EscapeString(row.Name),
+				formatAvailability(pct, ok), formatIncidentStats(row)))
+		case sla.KindServer:
+			sb.WriteString(fmt.Sprintf("  %s %s %s%s\n", availabilityIcon(pct, ok), html.EscapeString(row.Name),
+				formatAvailability(pct, ok), formatIncidentStats(row)))
+		case sla.KindService:
+			if ok && pct >= 100 && row.Incidents == 0 {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("     └ %s %s%s\n", html.EscapeString(row.Name),
+				formatAvailability(pct, ok), formatIncidentStats(row)))
+		}
+	}
+
+	if len(report.Rows) == 0 {
+		sb.WriteString("\nNo servers configured.")
+	}
+	return sb.String()
+}
+
+// periodLabel returns "24 hours", "7 days" or "30 days"
+func periodLabel(period string) string {
+	switch period {
+	case config.PeriodDay:
+		return "24 hours"
+	case config.PeriodWeek:
+		return "7 days"
+	default:
+		return "30 days"
+	}
+}
+
+// formatAvailability formats an availability percentage
+func formatAvailability(pct float64, ok bool) string {
+	if !ok {
+		return "no data"
+	}
+	return fmt.Sprintf("%.2f%%", pct)
+}
+
+// formatIncidentStats formats " · 2 incidents · MTTR 12m" (empty without incidents)
+func formatIncidentStats(row sla.Row) string {
+	if row.Incidents == 0 {
+		return ""
+	}
+	text := fmt.Sprintf(" · %d incident", row.Incidents)
+	if row.Incidents > 1 {
+		text += "s"
+	}
+	if row.MTTR > 0 {
+		text += " · MTTR " + health.FormatDuration(row.MTTR)
+	}
+	return text
+}
+
+// availabilityIcon returns the icon for an availability percentage
+func availabilityIcon(pct float64, ok bool) string {
+	switch {
+	case !ok:
+		return "⚪"
+	case pct >= 99.9:
+		return "🟢"
+	case pct >= 99:
+		return "🟡"
+	default:
+		return "🛑"
+	}
+}
+
+// buildSLAKeyboard builds period buttons (current one marked) and CSV export
+func buildSLAKeyboard(period string) tgbotapi.InlineKeyboardMarkup {
+	var periods []tgbotapi.InlineKeyboardButton
+	for _, p := range []string{config.PeriodDay, config.PeriodWeek, config.PeriodMonth} {
+		label := strings.ToUpper(p[:1]) + p[1:]
+		if p == period {
+			label = "• " + label
+		}
+		periods = append(periods, tgbotapi.NewInlineKeyboardButtonData(label, "sla:show:"+p))
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(
+		periods,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📄 CSV", "sla:csv:"+period),
+			tgbotapi.NewInlineKeyboardButtonData("🔄 Refresh", "sla:show:"+period),
+		),
+	)
+}
+
+// sendSLACSV sends the report for a period as a CSV document
+func (b *Bot) sendSLACSV(chatID int64, period string) {
+	report, err := b.buildSLAReport(period)
+	if err != nil {
+		b.reply(chatID, fmt.Sprintf("❌ %s", html.EscapeString(err.Error())))
+		return
+	}
+	data, err := report.CSV()
+	if err != nil {
+		b.reply(chatID, fmt.Sprintf("❌ %s", html.EscapeString(err.Error())))
+		return
+	}
+	name := fmt.Sprintf("sla-%s-%s.csv", period, report.End.Format("2006-01-02"))
+	b.sendDocument(chatID, name, data, fmt.Sprintf("📄 Availability, last %s", periodLabel(period)))
+}
+
+// handleSLACallback handles report buttons (sla:show:PERIOD, sla:csv:PERIOD)
+func (b *Bot) handleSLACallback(callback *tgbotapi.CallbackQuery, parts []string) {
+	if b.availability == nil {
+		b.answerCallback(callback.ID, "❌ Not enabled")
+		return
+	}
+	if len(parts) < 3 {
+		b.answerCallback(callback.ID, "❌ Invalid period")
+		return
+	}
+
+	period := parts[2]
+	switch parts[1] {
+	case "show":
+		text, keyboard := b.buildSLAMessage(period)
+		b.editMessageWithKeyboard(callback.Message.Chat.ID, callback.Message.MessageID, text, keyboard)
+		b.answerCallback(callback.ID, "")
+	case "csv":
+		b.answerCallback(callback.ID, "📄 Exporting...")
+		b.sendSLACSV(callback.Message.Chat.ID, period)
+	default:
+		b.answerCallback(callback.ID, "❌ Unknown action")
+	}
+}
+
+// runSLAReports sends the scheduled availability report until the bot stops
+func (b *Bot) runSLAReports() {
+	cfg := b.config.Infrastructure.SLAReport
+	for {
+		next, err := cfg.Next(time.Now())
+		if err != nil {
+			log.Printf("SLA report: %v", err)
+			return
+		}
+		log.Printf("SLA report: next at %s", next.Format(time.RFC3339))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-b.stop:
+			timer.Stop()
+			return
+		}
+
+		text, _ := b.buildSLAMessage(cfg.Period)
+		if err := b.SendNotification(text); err != nil {
+			log.Printf("SLA report: failed to send: %v", err)
+		}
+		if cfg.CSV {
+			for _, chatID := range b.config.Telegram.AllowedChatIDs {
+				b.sendSLACSV(chatID, cfg.Period)
+			}
+		}
+	}
+}
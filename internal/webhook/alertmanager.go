@@ -0,0 +1,235 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/health"
+	"github.com/scinfra-pro/scinfra-bot/internal/metrics"
+)
+
+// maxAlertsPerGroup limits alerts listed per status in one notification
+const maxAlertsPerGroup = 10
+
+// AlertmanagerPayload is the Alertmanager webhook payload (version 4)
+type AlertmanagerPayload struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+	Status            string            `json:"status"` // firing or resolved
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []Alert           `json:"alerts"`
+}
+
+// Alert is one alert of an Alertmanager payload
+type Alert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// handleAlertmanager handles webhooks from Alertmanager
+func (s *Server) handleAlertmanager(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.authorized(r) {
+		log.Printf("WARN: Alertmanager webhook unauthorized from %s", r.RemoteAddr)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var payload AlertmanagerPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		log.Printf("WARN: Alertmanager webhook bad request: %v", err)
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("INFO: Alertmanager webhook received: %s, %d alerts (receiver %s)",
+		payload.Status, len(payload.Alerts), payload.Receiver)
+	metrics.WebhookEventsTotal.Inc("alertmanager", payload.Status)
+
+	payload.Alerts = s.unsilencedAlerts(payload.Alerts)
+	if text := s.formatAlertmanager(&payload); text != "" {
+		if err := s.notifier.SendNotification(text); err != nil {
+			log.Printf("ERROR: Failed to send notification: %v", err)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// formatAlertmanager formats an Alertmanager payload with firing alerts first
+func (s *Server) formatAlertmanager(payload *AlertmanagerPayload) string {
+	var firing, resolved []Alert
+	for _, alert := range payload.Alerts {
+		if alert.Status == "resolved" {
+			resolved = append(resolved, alert)
+		} else {
+			firing = append(firing, alert)
+		}
+	}
+	if len(firing) == 0 && len(resolved) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	title := "🔥 <b>Alertmanager</b>"
+	if len(firing) == 0 {
+		title = "✅ <b>Alertmanager</b>"
+	}
+	var counts []string
+	if len(firing) > 0 {
+		counts = append(counts, fmt.Sprintf("%d firing", len(firing)))
+	}
+	if len(resolved) > 0 {
+		counts = append(counts, fmt.Sprintf("%d resolved", len(resolved)))
+	}
+	sb.WriteString(fmt.Sprintf("%s — %s\n", title, strings.Join(counts, ", ")))
+
+	if len(firing) > 0 {
+		sb.WriteString("\n<b>Firing:</b>\n")
+		s.writeAlerts(&sb, firing, false)
+	}
+	if len(resolved) > 0 {
+		sb.WriteString("\n<b>Resolved:</b>\n")
+		s.writeAlerts(&sb, resolved, true)
+	}
+	if payload.TruncatedAlerts > 0 {
+		sb.WriteString(fmt.Sprintf("\n<i>%d more alerts truncated by Alertmanager</i>\n", payload.TruncatedAlerts))
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// writeAlerts writes alerts sorted by severity and name
+func (s *Server) writeAlerts(sb *strings.Builder, alerts []Alert, resolved bool) {
+	sort.SliceStable(alerts, func(i, j int) bool {
+		ri, rj := severityRank(alerts[i].Labels["severity"]), severityRank(alerts[j].Labels["severity"])
+		if ri != rj {
+			return ri < rj
+		}
+		return alerts[i].Labels["alertname"] < alerts[j].Labels["alertname"]
+	})
+
+	for i, alert := range alerts {
+		if i == maxAlertsPerGroup {
+			sb.WriteString(fmt.Sprintf("… and %d more\n", len(alerts)-maxAlertsPerGroup))
+			break
+		}
+
+		icon := severityIcon(alert.Labels["severity"])
+		if resolved {
+			icon = "✅"
+		}
+		name := alert.Labels["alertname"]
+		if name == "" {
+			name = "alert"
+		}
+		line := fmt.Sprintf("%s <b>%s</b>", icon, html.EscapeString(name))
+		if target := s.alertTarget(alert.Labels); target != "" {
+			line += " · " + target
+		}
+		if resolved && !alert.EndsAt.IsZero() && !alert.StartsAt.IsZero() {
+			line += fmt.Sprintf(" (lasted %s)", health.FormatDuration(alert.EndsAt.Sub(alert.StartsAt)))
+		}
+		sb.WriteString(line + "\n")
+
+		if summary := alertSummary(alert.Annotations); summary != "" && !resolved {
+			sb.WriteString(fmt.Sprintf("   %s\n", html.EscapeString(summary)))
+		}
+		if !resolved && !alert.StartsAt.IsZero() {
+			sb.WriteString(fmt.Sprintf("   <i>since %s</i>\n", alert.StartsAt.Local().Format("Jan 2 15:04")))
+		}
+	}
+}
+
+// unsilencedAlerts drops alerts whose instance belongs to a silenced server
+// (manual silences and maintenance windows, as for health alerts)
+func (s *Server) unsilencedAlerts(alerts []Alert) []Alert {
+	if s.silencer == nil {
+		return alerts
+	}
+
+	kept := alerts[:0]
+	for _, alert := range alerts {
+		if server := s.config.GetServerByInstance(alert.Labels["instance"]); server != nil && s.silencer.IsSilenced(server.ID, "") {
+			log.Printf("INFO: Alertmanager alert %s for %s suppressed (silenced)", alert.Labels["alertname"], server.ID)
+			continue
+		}
+		kept = append(kept, alert)
+	}
+	return kept
+}
+
+// alertTarget returns the configured server name and icon for the instance
+// label, or the raw instance/job label if the server is unknown
+func (s *Server) alertTarget(labels map[string]string) string {
+	instance := labels["instance"]
+	if instance != "" {
+		if server := s.config.GetServerByInstance(instance); server != nil {
+			return fmt.Sprintf("%s %s", server.Icon, html.EscapeString(server.Name))
+		}
+		return fmt.Sprintf("<code>%s</code>", html.EscapeString(instance))
+	}
+	if job := labels["job"]; job != "" {
+		return fmt.Sprintf("<code>%s</code>", html.EscapeString(job))
+	}
+	return ""
+}
+
+// alertSummary returns the first line of the summary, description or message annotation
+func alertSummary(annotations map[string]string) string {
+	for _, key := range []string{"summary", "description", "message"} {
+		if v := strings.TrimSpace(annotations[key]); v != "" {
+			line, _, _ := strings.Cut(v, "\n")
+			return strings.TrimSpace(line)
+		}
+	}
+	return ""
+}
+
+// severityRank orders severities from most to least severe
+func severityRank(severity string) int {
+	switch strings.ToLower(severity) {
+	case "critical", "page":
+		return 0
+	case "warning":
+		return 1
+	case "info":
+		return 2
+	default:
+		return 3
+	}
+}
+
+// severityIcon returns the icon for an alert severity label
+func severityIcon(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical", "page":
+		return "🔴"
+	case "warning":
+		return "🟡"
+	case "info":
+		return "🔵"
+	default:
+		return "🟠"
+	}
+}
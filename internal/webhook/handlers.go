@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"net/http"
+	"text/template"
 	"time"
 )
 
@@ -16,85 +16,94 @@ type Event struct {
 	Payload   map[string]interface{} `json:"payload"`
 }
 
-// handleSwitchGate handles webhooks from switch-gate
-func (s *Server) handleSwitchGate(w http.ResponseWriter, r *http.Request) {
-	// Only accept POST
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+// switchGateTemplates are the built-in messages per switch-gate event
+var switchGateTemplates = map[string]*template.Template{
+	"mode.changed": template.Must(parseTemplate("mode.changed",
+		`{{if eq .trigger "limit_reached"}}⚠️{{else}}🔄{{end}} <b>{{capitalize .source}} VPS</b>
+
+Mode: {{.from}} → {{.to}}`)),
+	"limit.reached": template.Must(parseTemplate("limit.reached",
+		`⚠️ <b>{{capitalize .source}} VPS</b>
+
+Home limit reached: {{printf "%.0f" (number .used_mb)}}/{{printf "%.0f" (number .limit_mb)}} MB
+Auto-switched to: {{.switched_to}}`)),
+}
+
+// switchGateFallback renders switch-gate events without a built-in template
+var switchGateFallback = template.Must(parseTemplate("switch-gate",
+	`ℹ️ <b>{{capitalize .source}} VPS</b>
+
+Event: <code>{{.event}}</code>{{range $key, $value := .body.payload}}
+{{$key}}: {{$value}}{{end}}`))
+
+// switchGateRoute returns the built-in route for switch-gate events
+func (s *Server) switchGateRoute() *route {
+	return &route{
+		name: "switch-gate",
+		path: "/webhook/switch-gate",
+		fields: map[string]string{
+			"event":       "event",
+			"source":      "source",
+			"from":        "payload.from",
+			"to":          "payload.to",
+			"trigger":     "payload.trigger",
+			"used_mb":     "payload.used_mb",
+			"limit_mb":    "payload.limit_mb",
+			"switched_to": "payload.switched_to",
+		},
+		template: func(data map[string]any) *template.Template {
+			name, _ := data["event"].(string)
+			if tmpl, ok := switchGateTemplates[name]; ok {
+				return tmpl
+			}
+			log.Printf("WARN: Unknown event type: %s (using fallback template)", name)
+			return switchGateFallback
+		},
+		event: func(data map[string]any) string {
+			name, _ := data["event"].(string)
+			return name
+		},
+		received: s.recordSwitchGateEvent,
 	}
+}
 
-	// Validate secret
-	if r.Header.Get("X-Webhook-Secret") != s.secret {
-		log.Printf("WARN: Webhook unauthorized from %s", r.RemoteAddr)
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+// recordSwitchGateEvent passes a switch-gate event to the event recorder
+func (s *Server) recordSwitchGateEvent(body any) {
+	if s.recorder == nil {
 		return
 	}
 
-	// Parse event
+	// Re-decode the generic body into the typed event
+	data, err := json.Marshal(body)
+	if err != nil {
+		return
+	}
 	var event Event
-	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-		log.Printf("WARN: Webhook bad request: %v", err)
-		http.Error(w, "Bad Request", http.StatusBadRequest)
+	if err := json.Unmarshal(data, &event); err != nil {
 		return
 	}
 
-	log.Printf("INFO: Webhook received: %s from %s", event.Name, event.Source)
-
-	// Format and send notification
-	text := formatNotification(event)
-	if text != "" {
-		if err := s.notifier.SendNotification(text); err != nil {
-			log.Printf("ERROR: Failed to send notification: %v", err)
-		}
+	at := event.Timestamp
+	if at.IsZero() {
+		at = time.Now()
 	}
-
-	w.WriteHeader(http.StatusOK)
+	s.recorder.RecordWebhookEvent(event.Source, event.Name, formatSummary(event), at)
 }
 
-// formatNotification formats event into Telegram message
-func formatNotification(event Event) string {
-	source := capitalize(event.Source)
-
+// formatSummary formats event as a one-line plain text summary
+func formatSummary(event Event) string {
 	switch event.Name {
 	case "mode.changed":
-		return formatModeChanged(source, event.Payload)
+		return fmt.Sprintf("mode %s → %s", getStringPayload(event.Payload, "from"), getStringPayload(event.Payload, "to"))
 	case "limit.reached":
-		return formatLimitReached(source, event.Payload)
+		return fmt.Sprintf("home limit reached %.0f/%.0f MB, switched to %s",
+			getFloatPayload(event.Payload, "used_mb"), getFloatPayload(event.Payload, "limit_mb"),
+			getStringPayload(event.Payload, "switched_to"))
 	default:
-		log.Printf("WARN: Unknown event type: %s", event.Name)
 		return ""
 	}
 }
 
-// formatModeChanged formats mode.changed event
-func formatModeChanged(source string, payload map[string]interface{}) string {
-	from := getStringPayload(payload, "from")
-	to := getStringPayload(payload, "to")
-	trigger := getStringPayload(payload, "trigger")
-
-	icon := "🔄"
-	if trigger == "limit_reached" {
-		icon = "⚠️"
-	}
-
-	return fmt.Sprintf(`%s <b>%s VPS</b>
-
-Mode: %s → %s`, icon, source, from, to)
-}
-
-// formatLimitReached formats limit.reached event
-func formatLimitReached(source string, payload map[string]interface{}) string {
-	usedMB := getFloatPayload(payload, "used_mb")
-	limitMB := getFloatPayload(payload, "limit_mb")
-	switchedTo := getStringPayload(payload, "switched_to")
-
-	return fmt.Sprintf(`⚠️ <b>%s VPS</b>
-
-Home limit reached: %.0f/%.0f MB
-Auto-switched to: %s`, source, usedMB, limitMB, switchedTo)
-}
-
 // Helper functions
 
 func getStringPayload(payload map[string]interface{}, key string) string {
@@ -107,13 +116,16 @@ func getStringPayload(payload map[string]interface{}, key string) string {
 }
 
 func getFloatPayload(payload map[string]interface{}, key string) float64 {
-	if val, ok := payload[key]; ok {
-		switch v := val.(type) {
-		case float64:
-			return v
-		case int:
-			return float64(v)
-		}
+	return toFloat(payload[key])
+}
+
+// toFloat returns a numeric value as float64 (0 for anything else)
+func toFloat(val any) float64 {
+	switch v := val.(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
 	}
 	return 0
 }
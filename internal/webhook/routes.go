@@ -0,0 +1,218 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/config"
+	"github.com/scinfra-pro/scinfra-bot/internal/metrics"
+)
+
+// bodyField is the template field holding the whole (escaped) JSON body
+const bodyField = "body"
+
+// route renders JSON posted to one path into a notification
+type route struct {
+	name   string
+	path   string
+	secret string            // empty: webhooks.secret or basic auth
+	fields map[string]string // template field -> JSON path
+
+	// template picks the message template for a payload (nil: nothing to send)
+	template func(data map[string]any) *template.Template
+	// event returns the event type for logs and metrics
+	event func(data map[string]any) string
+	// received is called for every accepted payload (optional)
+	received func(body any)
+}
+
+// templateFuncs are available in route templates
+var templateFuncs = template.FuncMap{
+	"capitalize": capitalize,
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"default": func(def, v any) any {
+		if v == nil || v == "" {
+			return def
+		}
+		return v
+	},
+	"number": toFloat,
+	"truncate": func(n int, s string) string {
+		if r := []rune(s); len(r) > n {
+			return string(r[:n]) + "…"
+		}
+		return s
+	},
+}
+
+// parseTemplate parses a route template with the route functions
+func parseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
+
+// newTemplateRoute creates a route from config
+func newTemplateRoute(cfg config.WebhookRoute) (*route, error) {
+	tmpl, err := parseTemplate(cfg.Name, cfg.Template)
+	if err != nil {
+		return nil, fmt.Errorf("webhook route %s: %w", cfg.Name, err)
+	}
+	return &route{
+		name:     cfg.Name,
+		path:     cfg.Path,
+		secret:   cfg.Secret,
+		fields:   cfg.Fields,
+		template: func(map[string]any) *template.Template { return tmpl },
+		event: func(data map[string]any) string {
+			if event, ok := data["event"].(string); ok && event != "" {
+				return event
+			}
+			return "received"
+		},
+	}, nil
+}
+
+// handleRoute handles POST requests of a route
+func (s *Server) handleRoute(rt *route) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if !s.routeAuthorized(rt, r) {
+			log.Printf("WARN: Webhook %s unauthorized from %s", rt.name, r.RemoteAddr)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var body any
+		decoder := json.NewDecoder(r.Body)
+		decoder.UseNumber()
+		if err := decoder.Decode(&body); err != nil {
+			log.Printf("WARN: Webhook %s bad request: %v", rt.name, err)
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		data := templateData(rt.fields, body)
+		event := html.UnescapeString(rt.event(data))
+		log.Printf("INFO: Webhook %s received: %s", rt.name, event)
+		metrics.WebhookEventsTotal.Inc(rt.name, event)
+
+		if rt.received != nil {
+			rt.received(body)
+		}
+
+		text, err := renderRoute(rt, data)
+		if err != nil {
+			log.Printf("ERROR: Webhook %s template failed: %v", rt.name, err)
+			http.Error(w, "Template error", http.StatusInternalServerError)
+			return
+		}
+		if text != "" {
+			if err := s.notifier.SendNotification(text); err != nil {
+				log.Printf("ERROR: Failed to send notification: %v", err)
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// routeAuthorized checks the route's own secret (instead of webhooks.secret) or basic auth
+func (s *Server) routeAuthorized(rt *route, r *http.Request) bool {
+	if rt.secret == "" {
+		return s.authorized(r)
+	}
+	return secretMatches(r, rt.secret) || s.basicAuthorized(r)
+}
+
+// renderRoute executes the route template (empty text: nothing to send)
+func renderRoute(rt *route, data map[string]any) (string, error) {
+	tmpl := rt.template(data)
+	if tmpl == nil {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// templateData extracts fields from the body (missing fields are "") and adds
+// the whole body as "body". All strings are HTML-escaped.
+func templateData(fields map[string]string, body any) map[string]any {
+	escaped := escapeValue(body)
+	data := map[string]any{bodyField: escaped}
+	for name, path := range fields {
+		if v, ok := lookupPath(escaped, path); ok {
+			data[name] = v
+		} else {
+			data[name] = ""
+		}
+	}
+	return data
+}
+
+// lookupPath returns the value at a dot-separated path ("alerts.0.labels.alertname")
+func lookupPath(v any, path string) (any, bool) {
+	if path == "" {
+		return v, true
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			next, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// escapeValue HTML-escapes strings (and object keys) in a decoded JSON value
+// Numbers become float64 so templates can format them with printf
+func escapeValue(v any) any {
+	switch val := v.(type) {
+	case string:
+		return html.EscapeString(val)
+	case json.Number:
+		if f, err := val.Float64(); err == nil {
+			return f
+		}
+		return val.String()
+	case map[string]any:
+		escaped := make(map[string]any, len(val))
+		for k, item := range val {
+			escaped[html.EscapeString(k)] = escapeValue(item)
+		}
+		return escaped
+	case []any:
+		escaped := make([]any, len(val))
+		for i, item := range val {
+			escaped[i] = escapeValue(item)
+		}
+		return escaped
+	default:
+		return val
+	}
+}
@@ -2,9 +2,14 @@ package webhook
 
 import (
 	"context"
+	"crypto/subtle"
 	"log"
 	"net/http"
 	"time"
+
+	"github.com/scinfra-pro/scinfra-bot/internal/config"
+	"github.com/scinfra-pro/scinfra-bot/internal/health"
+	"github.com/scinfra-pro/scinfra-bot/internal/metrics"
 )
 
 // TelegramNotifier interface for sending notifications
@@ -12,28 +17,67 @@ type TelegramNotifier interface {
 	SendNotification(text string) error
 }
 
+// EventRecorder records received events (e.g. for incident history)
+type EventRecorder interface {
+	RecordWebhookEvent(source, name, summary string, at time.Time)
+}
+
 // Server handles incoming webhooks
 type Server struct {
+	config     *config.Config // servers for mapping alert labels
 	listenAddr string
 	secret     string
 	notifier   TelegramNotifier
+	recorder   EventRecorder   // optional
+	silencer   health.Silencer // optional, suppresses Alertmanager alerts of silenced servers
+	routes     []*route        // switch-gate and configured template routes
 	httpServer *http.Server
 }
 
-// NewServer creates a new webhook server
-func NewServer(listenAddr, secret string, notifier TelegramNotifier) *Server {
-	return &Server{
-		listenAddr: listenAddr,
-		secret:     secret,
+// NewServer creates a new webhook server from cfg.Webhooks
+// Returns an error if a route template does not parse
+func NewServer(cfg *config.Config, notifier TelegramNotifier) (*Server, error) {
+	s := &Server{
+		config:     cfg,
+		listenAddr: cfg.Webhooks.Listen,
+		secret:     cfg.Webhooks.Secret,
 		notifier:   notifier,
 	}
+
+	s.routes = append(s.routes, s.switchGateRoute())
+	for _, routeCfg := range cfg.Webhooks.Routes {
+		rt, err := newTemplateRoute(routeCfg)
+		if err != nil {
+			return nil, err
+		}
+		s.routes = append(s.routes, rt)
+		log.Printf("Webhook route %s on %s", rt.name, rt.path)
+	}
+
+	return s, nil
+}
+
+// SetEventRecorder sets the recorder for received events
+// Must be called before Start
+func (s *Server) SetEventRecorder(r EventRecorder) {
+	s.recorder = r
+}
+
+// SetSilencer sets the silence source for suppressing Alertmanager alerts
+// Must be called before Start
+func (s *Server) SetSilencer(silencer health.Silencer) {
+	s.silencer = silencer
 }
 
 // Start starts the webhook server
 func (s *Server) Start() error {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/webhook/switch-gate", s.handleSwitchGate)
+	for _, rt := range s.routes {
+		mux.HandleFunc(rt.path, s.handleRoute(rt))
+	}
+	mux.HandleFunc("/webhook/alertmanager", s.handleAlertmanager)
 	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/metrics", s.handleMetrics)
 
 	s.httpServer = &http.Server{
 		Addr:         s.listenAddr,
@@ -59,6 +103,46 @@ func (s *Server) Stop() error {
 	return s.httpServer.Shutdown(ctx)
 }
 
+// handleMetrics serves bot metrics in Prometheus text format
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if token := s.config.Webhooks.MetricsToken; token != "" {
+		expected := "Bearer " + token
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expected)) != 1 {
+			log.Printf("WARN: Metrics unauthorized from %s", r.RemoteAddr)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+	metrics.Default.Handler().ServeHTTP(w, r)
+}
+
+// authorized reports whether a webhook request carries the shared secret
+// (X-Webhook-Secret) or, if configured, matching basic auth credentials
+func (s *Server) authorized(r *http.Request) bool {
+	return secretMatches(r, s.secret) || s.basicAuthorized(r)
+}
+
+// secretMatches reports whether the X-Webhook-Secret header equals secret
+// An empty secret never matches (a missing header must not authorize)
+func secretMatches(r *http.Request, secret string) bool {
+	if secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Webhook-Secret")), []byte(secret)) == 1
+}
+
+// basicAuthorized reports whether the request has the configured basic auth credentials
+func (s *Server) basicAuthorized(r *http.Request) bool {
+	auth := s.config.Webhooks.BasicAuth
+	if auth.Username == "" {
+		return false
+	}
+	user, pass, ok := r.BasicAuth()
+	return ok &&
+		subtle.ConstantTimeCompare([]byte(user), []byte(auth.Username)) == 1 &&
+		subtle.ConstantTimeCompare([]byte(pass), []byte(auth.Password)) == 1
+}
+
 // handleHealth returns 200 OK for health checks
 func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)